@@ -19,9 +19,13 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type Plugin struct {
-	Name                 string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	Version              string   `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
-	Requester            string   `protobuf:"bytes,3,opt,name=requester" json:"requester,omitempty"`
+	Name      string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Version   string `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
+	Requester string `protobuf:"bytes,3,opt,name=requester" json:"requester,omitempty"`
+	// required_by lists the plugins that depend on this one (lock files only)
+	RequiredBy []string `protobuf:"bytes,4,rep,name=required_by,json=requiredBy" json:"required_by,omitempty"`
+	// optional means that the plugin is only an optional dependency (lock files only)
+	Optional             bool     `protobuf:"varint,5,opt,name=optional" json:"optional,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -31,7 +35,7 @@ func (m *Plugin) Reset()         { *m = Plugin{} }
 func (m *Plugin) String() string { return proto.CompactTextString(m) }
 func (*Plugin) ProtoMessage()    {}
 func (*Plugin) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pluginsapi_dc337896e4771f73, []int{0}
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{0}
 }
 func (m *Plugin) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Plugin.Unmarshal(m, b)
@@ -72,21 +76,39 @@ func (m *Plugin) GetRequester() string {
 	return ""
 }
 
+func (m *Plugin) GetRequiredBy() []string {
+	if m != nil {
+		return m.RequiredBy
+	}
+	return nil
+}
+
+func (m *Plugin) GetOptional() bool {
+	if m != nil {
+		return m.Optional
+	}
+	return false
+}
+
 type PluginMetadata struct {
 	FullName             string    `protobuf:"bytes,1,opt,name=full_name,json=fullName" json:"full_name,omitempty"`
 	Plugin               *Plugin   `protobuf:"bytes,2,opt,name=plugin" json:"plugin,omitempty"`
 	Dependencies         []*Plugin `protobuf:"bytes,3,rep,name=dependencies" json:"dependencies,omitempty"`
 	OptionalDependencies []*Plugin `protobuf:"bytes,4,rep,name=optional_dependencies,json=optionalDependencies" json:"optional_dependencies,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+	// required_core is the minimum Jenkins core version (Jenkins-Version header)
+	RequiredCore string `protobuf:"bytes,5,opt,name=required_core,json=requiredCore" json:"required_core,omitempty"`
+	// schema is the version of the metadata format (stored metadata only)
+	Schema               int32    `protobuf:"varint,6,opt,name=schema" json:"schema,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *PluginMetadata) Reset()         { *m = PluginMetadata{} }
 func (m *PluginMetadata) String() string { return proto.CompactTextString(m) }
 func (*PluginMetadata) ProtoMessage()    {}
 func (*PluginMetadata) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pluginsapi_dc337896e4771f73, []int{1}
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{1}
 }
 func (m *PluginMetadata) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_PluginMetadata.Unmarshal(m, b)
@@ -134,18 +156,36 @@ func (m *PluginMetadata) GetOptionalDependencies() []*Plugin {
 	return nil
 }
 
+func (m *PluginMetadata) GetRequiredCore() string {
+	if m != nil {
+		return m.RequiredCore
+	}
+	return ""
+}
+
+func (m *PluginMetadata) GetSchema() int32 {
+	if m != nil {
+		return m.Schema
+	}
+	return 0
+}
+
 type PluginsRegistry struct {
-	Plugins              []*Plugin `protobuf:"bytes,1,rep,name=plugins" json:"plugins,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+	Plugins []*Plugin `protobuf:"bytes,1,rep,name=plugins" json:"plugins,omitempty"`
+	// jenkins is the core the plugins were resolved for (lock files only)
+	Jenkins *Core `protobuf:"bytes,2,opt,name=jenkins" json:"jenkins,omitempty"`
+	// fingerprint is the hash of the inputs the lock file was generated from (lock files only)
+	Fingerprint          string   `protobuf:"bytes,3,opt,name=fingerprint" json:"fingerprint,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *PluginsRegistry) Reset()         { *m = PluginsRegistry{} }
 func (m *PluginsRegistry) String() string { return proto.CompactTextString(m) }
 func (*PluginsRegistry) ProtoMessage()    {}
 func (*PluginsRegistry) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pluginsapi_dc337896e4771f73, []int{2}
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{2}
 }
 func (m *PluginsRegistry) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_PluginsRegistry.Unmarshal(m, b)
@@ -172,8 +212,104 @@ func (m *PluginsRegistry) GetPlugins() []*Plugin {
 	return nil
 }
 
+func (m *PluginsRegistry) GetJenkins() *Core {
+	if m != nil {
+		return m.Jenkins
+	}
+	return nil
+}
+
+func (m *PluginsRegistry) GetFingerprint() string {
+	if m != nil {
+		return m.Fingerprint
+	}
+	return ""
+}
+
+// Fingerprint represents the inputs a lock file is generated from
+type Fingerprint struct {
+	Project         *Project `protobuf:"bytes,1,opt,name=project" json:"project,omitempty"`
+	JenkinsVersion  string   `protobuf:"bytes,2,opt,name=jenkins_version,json=jenkinsVersion" json:"jenkins_version,omitempty"`
+	Optional        bool     `protobuf:"varint,3,opt,name=optional" json:"optional,omitempty"`
+	ResolverVersion string   `protobuf:"bytes,4,opt,name=resolver_version,json=resolverVersion" json:"resolver_version,omitempty"`
+	// strategy is the resolution strategy (empty for the default one)
+	Strategy string `protobuf:"bytes,5,opt,name=strategy" json:"strategy,omitempty"`
+	// catalog is the checksum of the catalog files the strategy is based on
+	Catalog              string   `protobuf:"bytes,6,opt,name=catalog" json:"catalog,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Fingerprint) Reset()         { *m = Fingerprint{} }
+func (m *Fingerprint) String() string { return proto.CompactTextString(m) }
+func (*Fingerprint) ProtoMessage()    {}
+func (*Fingerprint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{3}
+}
+func (m *Fingerprint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Fingerprint.Unmarshal(m, b)
+}
+func (m *Fingerprint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Fingerprint.Marshal(b, m, deterministic)
+}
+func (dst *Fingerprint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Fingerprint.Merge(dst, src)
+}
+func (m *Fingerprint) XXX_Size() int {
+	return xxx_messageInfo_Fingerprint.Size(m)
+}
+func (m *Fingerprint) XXX_DiscardUnknown() {
+	xxx_messageInfo_Fingerprint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Fingerprint proto.InternalMessageInfo
+
+func (m *Fingerprint) GetProject() *Project {
+	if m != nil {
+		return m.Project
+	}
+	return nil
+}
+
+func (m *Fingerprint) GetJenkinsVersion() string {
+	if m != nil {
+		return m.JenkinsVersion
+	}
+	return ""
+}
+
+func (m *Fingerprint) GetOptional() bool {
+	if m != nil {
+		return m.Optional
+	}
+	return false
+}
+
+func (m *Fingerprint) GetResolverVersion() string {
+	if m != nil {
+		return m.ResolverVersion
+	}
+	return ""
+}
+
+func (m *Fingerprint) GetStrategy() string {
+	if m != nil {
+		return m.Strategy
+	}
+	return ""
+}
+
+func (m *Fingerprint) GetCatalog() string {
+	if m != nil {
+		return m.Catalog
+	}
+	return ""
+}
+
 type Graph struct {
 	Nodes                []*Graph_Node `protobuf:"bytes,1,rep,name=nodes" json:"nodes,omitempty"`
+	Key                  *GraphKey     `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
 	XXX_unrecognized     []byte        `json:"-"`
 	XXX_sizecache        int32         `json:"-"`
@@ -183,7 +319,7 @@ func (m *Graph) Reset()         { *m = Graph{} }
 func (m *Graph) String() string { return proto.CompactTextString(m) }
 func (*Graph) ProtoMessage()    {}
 func (*Graph) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pluginsapi_dc337896e4771f73, []int{3}
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{4}
 }
 func (m *Graph) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Graph.Unmarshal(m, b)
@@ -210,6 +346,13 @@ func (m *Graph) GetNodes() []*Graph_Node {
 	return nil
 }
 
+func (m *Graph) GetKey() *GraphKey {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
 type Graph_Node struct {
 	Plugin               *Plugin       `protobuf:"bytes,1,opt,name=plugin" json:"plugin,omitempty"`
 	Dependencies         []*Graph_Node `protobuf:"bytes,3,rep,name=dependencies" json:"dependencies,omitempty"`
@@ -223,7 +366,7 @@ func (m *Graph_Node) Reset()         { *m = Graph_Node{} }
 func (m *Graph_Node) String() string { return proto.CompactTextString(m) }
 func (*Graph_Node) ProtoMessage()    {}
 func (*Graph_Node) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pluginsapi_dc337896e4771f73, []int{3, 0}
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{4, 0}
 }
 func (m *Graph_Node) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Graph_Node.Unmarshal(m, b)
@@ -264,20 +407,95 @@ func (m *Graph_Node) GetOptionalDependencies() []*Graph_Node {
 	return nil
 }
 
+// GraphKey identifies a graph in the store. Graphs are only reused
+// if they were computed for the same key.
+type GraphKey struct {
+	// schema is the version of the graph format
+	Schema               int32     `protobuf:"varint,1,opt,name=schema" json:"schema,omitempty"`
+	Plugins              []*Plugin `protobuf:"bytes,2,rep,name=plugins" json:"plugins,omitempty"`
+	Optional             bool      `protobuf:"varint,3,opt,name=optional" json:"optional,omitempty"`
+	JenkinsVersion       string    `protobuf:"bytes,4,opt,name=jenkins_version,json=jenkinsVersion" json:"jenkins_version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *GraphKey) Reset()         { *m = GraphKey{} }
+func (m *GraphKey) String() string { return proto.CompactTextString(m) }
+func (*GraphKey) ProtoMessage()    {}
+func (*GraphKey) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{5}
+}
+func (m *GraphKey) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GraphKey.Unmarshal(m, b)
+}
+func (m *GraphKey) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GraphKey.Marshal(b, m, deterministic)
+}
+func (dst *GraphKey) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GraphKey.Merge(dst, src)
+}
+func (m *GraphKey) XXX_Size() int {
+	return xxx_messageInfo_GraphKey.Size(m)
+}
+func (m *GraphKey) XXX_DiscardUnknown() {
+	xxx_messageInfo_GraphKey.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GraphKey proto.InternalMessageInfo
+
+func (m *GraphKey) GetSchema() int32 {
+	if m != nil {
+		return m.Schema
+	}
+	return 0
+}
+
+func (m *GraphKey) GetPlugins() []*Plugin {
+	if m != nil {
+		return m.Plugins
+	}
+	return nil
+}
+
+func (m *GraphKey) GetOptional() bool {
+	if m != nil {
+		return m.Optional
+	}
+	return false
+}
+
+func (m *GraphKey) GetJenkinsVersion() string {
+	if m != nil {
+		return m.JenkinsVersion
+	}
+	return ""
+}
+
 // Project represents a file that lists the packages
 // your project depends on
 type Project struct {
-	Dependencies         map[string]string `protobuf:"bytes,1,rep,name=dependencies" json:"dependencies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
-	XXX_unrecognized     []byte            `json:"-"`
-	XXX_sizecache        int32             `json:"-"`
+	Dependencies map[string]string `protobuf:"bytes,1,rep,name=dependencies" json:"dependencies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Jenkins      *Core             `protobuf:"bytes,2,opt,name=jenkins" json:"jenkins,omitempty"`
+	// include lists other project files (relative to this one)
+	// the project is based on
+	Include []string `protobuf:"bytes,3,rep,name=include" json:"include,omitempty"`
+	// profiles are named variants of the project (e.g. dev, staging, prod)
+	Profiles map[string]*Profile `protobuf:"bytes,4,rep,name=profiles" json:"profiles,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// overrides forces the version of transitive plugins
+	Overrides map[string]string `protobuf:"bytes,5,rep,name=overrides" json:"overrides,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// excludes lists plugins that are never locked
+	Excludes             []string `protobuf:"bytes,6,rep,name=excludes" json:"excludes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Project) Reset()         { *m = Project{} }
 func (m *Project) String() string { return proto.CompactTextString(m) }
 func (*Project) ProtoMessage()    {}
 func (*Project) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pluginsapi_dc337896e4771f73, []int{4}
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{6}
 }
 func (m *Project) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Project.Unmarshal(m, b)
@@ -304,6 +522,156 @@ func (m *Project) GetDependencies() map[string]string {
 	return nil
 }
 
+func (m *Project) GetJenkins() *Core {
+	if m != nil {
+		return m.Jenkins
+	}
+	return nil
+}
+
+func (m *Project) GetInclude() []string {
+	if m != nil {
+		return m.Include
+	}
+	return nil
+}
+
+func (m *Project) GetProfiles() map[string]*Profile {
+	if m != nil {
+		return m.Profiles
+	}
+	return nil
+}
+
+func (m *Project) GetOverrides() map[string]string {
+	if m != nil {
+		return m.Overrides
+	}
+	return nil
+}
+
+func (m *Project) GetExcludes() []string {
+	if m != nil {
+		return m.Excludes
+	}
+	return nil
+}
+
+// Profile represents a variant of a project
+type Profile struct {
+	// dependencies adds or overrides dependencies of the project
+	Dependencies map[string]string `protobuf:"bytes,1,rep,name=dependencies" json:"dependencies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// remove lists dependencies removed from the project
+	Remove               []string `protobuf:"bytes,2,rep,name=remove" json:"remove,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Profile) Reset()         { *m = Profile{} }
+func (m *Profile) String() string { return proto.CompactTextString(m) }
+func (*Profile) ProtoMessage()    {}
+func (*Profile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{7}
+}
+func (m *Profile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Profile.Unmarshal(m, b)
+}
+func (m *Profile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Profile.Marshal(b, m, deterministic)
+}
+func (dst *Profile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Profile.Merge(dst, src)
+}
+func (m *Profile) XXX_Size() int {
+	return xxx_messageInfo_Profile.Size(m)
+}
+func (m *Profile) XXX_DiscardUnknown() {
+	xxx_messageInfo_Profile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Profile proto.InternalMessageInfo
+
+func (m *Profile) GetDependencies() map[string]string {
+	if m != nil {
+		return m.Dependencies
+	}
+	return nil
+}
+
+func (m *Profile) GetRemove() []string {
+	if m != nil {
+		return m.Remove
+	}
+	return nil
+}
+
+// Core represents the Jenkins core a project runs on
+type Core struct {
+	Version string `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
+	// war is the path to a local war file
+	War string `protobuf:"bytes,2,opt,name=war" json:"war,omitempty"`
+	// url is the location to download the war from
+	Url string `protobuf:"bytes,3,opt,name=url" json:"url,omitempty"`
+	// checksum is the SHA-256 checksum of the war
+	Checksum             string   `protobuf:"bytes,4,opt,name=checksum" json:"checksum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Core) Reset()         { *m = Core{} }
+func (m *Core) String() string { return proto.CompactTextString(m) }
+func (*Core) ProtoMessage()    {}
+func (*Core) Descriptor() ([]byte, []int) {
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{8}
+}
+func (m *Core) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Core.Unmarshal(m, b)
+}
+func (m *Core) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Core.Marshal(b, m, deterministic)
+}
+func (dst *Core) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Core.Merge(dst, src)
+}
+func (m *Core) XXX_Size() int {
+	return xxx_messageInfo_Core.Size(m)
+}
+func (m *Core) XXX_DiscardUnknown() {
+	xxx_messageInfo_Core.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Core proto.InternalMessageInfo
+
+func (m *Core) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *Core) GetWar() string {
+	if m != nil {
+		return m.War
+	}
+	return ""
+}
+
+func (m *Core) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *Core) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
 type Jenkins struct {
 	Version              string            `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
 	Plugins              []*PluginMetadata `protobuf:"bytes,2,rep,name=plugins" json:"plugins,omitempty"`
@@ -316,7 +684,7 @@ func (m *Jenkins) Reset()         { *m = Jenkins{} }
 func (m *Jenkins) String() string { return proto.CompactTextString(m) }
 func (*Jenkins) ProtoMessage()    {}
 func (*Jenkins) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pluginsapi_dc337896e4771f73, []int{5}
+	return fileDescriptor_pluginsapi_1a1024ad28d0d93c, []int{9}
 }
 func (m *Jenkins) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Jenkins.Unmarshal(m, b)
@@ -354,40 +722,70 @@ func init() {
 	proto.RegisterType((*Plugin)(nil), "Plugin")
 	proto.RegisterType((*PluginMetadata)(nil), "PluginMetadata")
 	proto.RegisterType((*PluginsRegistry)(nil), "PluginsRegistry")
+	proto.RegisterType((*Fingerprint)(nil), "Fingerprint")
 	proto.RegisterType((*Graph)(nil), "Graph")
 	proto.RegisterType((*Graph_Node)(nil), "Graph.Node")
+	proto.RegisterType((*GraphKey)(nil), "GraphKey")
 	proto.RegisterType((*Project)(nil), "Project")
 	proto.RegisterMapType((map[string]string)(nil), "Project.DependenciesEntry")
+	proto.RegisterMapType((map[string]string)(nil), "Project.OverridesEntry")
+	proto.RegisterMapType((map[string]*Profile)(nil), "Project.ProfilesEntry")
+	proto.RegisterType((*Profile)(nil), "Profile")
+	proto.RegisterMapType((map[string]string)(nil), "Profile.DependenciesEntry")
+	proto.RegisterType((*Core)(nil), "Core")
 	proto.RegisterType((*Jenkins)(nil), "Jenkins")
 }
 
-func init() { proto.RegisterFile("pluginsapi.proto", fileDescriptor_pluginsapi_dc337896e4771f73) }
-
-var fileDescriptor_pluginsapi_dc337896e4771f73 = []byte{
-	// 385 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x52, 0x51, 0x6a, 0xdb, 0x40,
-	0x10, 0x65, 0x2d, 0xcb, 0xaa, 0xc7, 0xa5, 0x76, 0x17, 0x17, 0x84, 0x5b, 0xa8, 0xad, 0xaf, 0x96,
-	0x82, 0x0a, 0x6d, 0x3f, 0x4a, 0x29, 0x6d, 0x08, 0x09, 0x81, 0x40, 0x8c, 0x11, 0xf9, 0xca, 0x8f,
-	0xd9, 0x58, 0x13, 0x67, 0x63, 0x65, 0x57, 0x59, 0xad, 0x0c, 0xbe, 0x42, 0x8e, 0x90, 0x9b, 0xe4,
-	0x04, 0xb9, 0x56, 0xd0, 0x4a, 0x8a, 0xb5, 0x89, 0xc9, 0x9f, 0xe6, 0xcd, 0x7b, 0x33, 0xf3, 0xb4,
-	0x0f, 0x06, 0x69, 0x92, 0x2f, 0xb9, 0xc8, 0x58, 0xca, 0xc3, 0x54, 0x49, 0x2d, 0x83, 0x53, 0xe8,
-	0xcc, 0x0c, 0x46, 0x29, 0xb4, 0x05, 0xbb, 0x46, 0x9f, 0x8c, 0xc9, 0x97, 0x6e, 0x64, 0xbe, 0xa9,
-	0x0f, 0xde, 0x1a, 0x55, 0xc6, 0xa5, 0xf0, 0x5b, 0x06, 0xae, 0x4b, 0xfa, 0x09, 0xba, 0x0a, 0x6f,
-	0x72, 0xcc, 0x34, 0x2a, 0xdf, 0x31, 0xbd, 0x2d, 0x10, 0xdc, 0x13, 0x78, 0x57, 0x8e, 0x3d, 0x41,
-	0xcd, 0x62, 0xa6, 0x19, 0xfd, 0x08, 0xdd, 0x8b, 0x3c, 0x49, 0xe6, 0x8d, 0x1d, 0x6f, 0x0a, 0x60,
-	0x5a, 0xec, 0xf9, 0x0c, 0x9d, 0xf2, 0x32, 0xb3, 0xa6, 0xf7, 0xc3, 0x0b, 0x4b, 0x75, 0x54, 0xc1,
-	0xf4, 0x1b, 0xbc, 0x8d, 0x31, 0x45, 0x11, 0xa3, 0x58, 0x70, 0xcc, 0x7c, 0x67, 0xec, 0x34, 0x69,
-	0x56, 0x93, 0xfe, 0x85, 0x0f, 0x32, 0xd5, 0x5c, 0x0a, 0x96, 0xcc, 0x2d, 0x55, 0xdb, 0x56, 0x0d,
-	0x6b, 0xd6, 0x41, 0x83, 0x14, 0xfc, 0x82, 0x7e, 0xd9, 0xcf, 0x22, 0x5c, 0xf2, 0x4c, 0xab, 0x0d,
-	0x9d, 0x80, 0x57, 0xfd, 0x38, 0x9f, 0xd8, 0x23, 0x6a, 0x3c, 0x78, 0x20, 0xe0, 0x1e, 0x29, 0x96,
-	0x5e, 0xd2, 0x09, 0xb8, 0x42, 0xc6, 0x58, 0x53, 0x7b, 0xa1, 0x81, 0xc3, 0xa9, 0x8c, 0x31, 0x2a,
-	0x3b, 0xa3, 0x3b, 0x02, 0xed, 0xa2, 0x6e, 0xf8, 0x26, 0xbb, 0x7d, 0x7f, 0xdf, 0xe9, 0xdb, 0x9a,
-	0x69, 0x7b, 0xdf, 0x7b, 0xdd, 0xbb, 0xa5, 0xdc, 0xed, 0xff, 0x96, 0x80, 0x37, 0x53, 0xf2, 0x0a,
-	0x17, 0x9a, 0xfe, 0x7b, 0xb6, 0xbe, 0xb4, 0x34, 0x0a, 0xab, 0x7e, 0xd8, 0x14, 0x1e, 0x0a, 0xad,
-	0x36, 0xf6, 0x35, 0xa3, 0xff, 0xf0, 0xfe, 0x05, 0x85, 0x0e, 0xc0, 0x59, 0xe1, 0xa6, 0xca, 0x40,
-	0xf1, 0x49, 0x87, 0xe0, 0xae, 0x59, 0x92, 0x63, 0x15, 0xb2, 0xb2, 0xf8, 0xd3, 0xfa, 0x4d, 0x82,
-	0x29, 0x78, 0xc7, 0x28, 0x56, 0x5c, 0x64, 0xcd, 0x2c, 0x12, 0x3b, 0x8b, 0x5f, 0xb7, 0xcf, 0xd3,
-	0x32, 0x07, 0xf6, 0x43, 0x3b, 0x7c, 0x4f, 0xcf, 0xb4, 0xef, 0x9e, 0x39, 0x2c, 0xe5, 0xe7, 0x1d,
-	0x13, 0xfe, 0x9f, 0x8f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x6f, 0x98, 0xfa, 0x3f, 0x10, 0x03, 0x00,
-	0x00,
+func init() { proto.RegisterFile("pluginsapi.proto", fileDescriptor_pluginsapi_1a1024ad28d0d93c) }
+
+var fileDescriptor_pluginsapi_1a1024ad28d0d93c = []byte{
+	// 760 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0xcd, 0x6e, 0xd3, 0x58,
+	0x14, 0xd6, 0xad, 0xe3, 0x38, 0x3e, 0x69, 0x9b, 0xcc, 0x55, 0xa7, 0x63, 0xa5, 0xa3, 0x69, 0xea,
+	0x59, 0x4c, 0xaa, 0x91, 0x3c, 0x52, 0x47, 0x48, 0x08, 0x55, 0x80, 0x0a, 0x05, 0x09, 0x44, 0xa9,
+	0xbc, 0x60, 0xc1, 0x26, 0xb8, 0xce, 0x69, 0xea, 0xd6, 0xb1, 0xcd, 0xb5, 0x93, 0x92, 0x57, 0x60,
+	0xc7, 0x96, 0x25, 0x6f, 0xc4, 0x8a, 0x17, 0xe0, 0x19, 0x58, 0xa3, 0xfb, 0xe7, 0xd8, 0x25, 0x6d,
+	0x85, 0xd8, 0xf9, 0xfb, 0xce, 0x39, 0xf7, 0xfc, 0x7d, 0xd7, 0x17, 0xba, 0x59, 0x3c, 0x1d, 0x47,
+	0x49, 0x1e, 0x64, 0x91, 0x97, 0xb1, 0xb4, 0x48, 0xdd, 0x0f, 0x04, 0x9a, 0xc7, 0x82, 0xa4, 0x14,
+	0x1a, 0x49, 0x30, 0x41, 0x87, 0xf4, 0xc9, 0xc0, 0xf6, 0xc5, 0x37, 0x75, 0xc0, 0x9a, 0x21, 0xcb,
+	0xa3, 0x34, 0x71, 0x56, 0x04, 0xad, 0x21, 0xfd, 0x13, 0x6c, 0x86, 0x6f, 0xa7, 0x98, 0x17, 0xc8,
+	0x1c, 0x43, 0xd8, 0x16, 0x04, 0xdd, 0x86, 0x36, 0x07, 0x11, 0xc3, 0xd1, 0xf0, 0x64, 0xee, 0x34,
+	0xfa, 0xc6, 0xc0, 0xf6, 0x41, 0x53, 0x07, 0x73, 0xda, 0x83, 0x56, 0x9a, 0x15, 0x51, 0x9a, 0x04,
+	0xb1, 0x63, 0xf6, 0xc9, 0xa0, 0xe5, 0x97, 0xd8, 0xfd, 0x46, 0x60, 0x5d, 0xd6, 0xf4, 0x02, 0x8b,
+	0x60, 0x14, 0x14, 0x01, 0xdd, 0x02, 0xfb, 0x74, 0x1a, 0xc7, 0xc3, 0x4a, 0x81, 0x2d, 0x4e, 0x1c,
+	0xf1, 0x22, 0xb7, 0xa1, 0x29, 0xfb, 0x12, 0x35, 0xb6, 0xf7, 0x2c, 0x4f, 0x46, 0xfb, 0x8a, 0xa6,
+	0xff, 0xc2, 0xea, 0x08, 0x33, 0x4c, 0x46, 0x98, 0x84, 0x11, 0xe6, 0x8e, 0xd1, 0x37, 0xaa, 0x6e,
+	0x35, 0x23, 0xdd, 0x87, 0xdf, 0x75, 0x25, 0xc3, 0x5a, 0x54, 0xa3, 0x1e, 0xb5, 0xa1, 0xbd, 0x1e,
+	0x57, 0xa3, 0xff, 0x86, 0xb5, 0xb2, 0xf1, 0x30, 0x65, 0x28, 0x9a, 0xb3, 0xfd, 0x55, 0x4d, 0x3e,
+	0x4a, 0x19, 0xd2, 0x4d, 0x68, 0xe6, 0xe1, 0x19, 0x4e, 0x02, 0xa7, 0xd9, 0x27, 0x03, 0xd3, 0x57,
+	0xc8, 0xbd, 0x84, 0x8e, 0x3c, 0x3c, 0xf7, 0x71, 0x1c, 0xe5, 0x05, 0x9b, 0xd3, 0x1d, 0xb0, 0xd4,
+	0xce, 0x1c, 0x52, 0xcf, 0xaf, 0x79, 0xba, 0x0d, 0xd6, 0x39, 0x26, 0x17, 0xdc, 0x45, 0xf6, 0x6f,
+	0x7a, 0x3c, 0x8b, 0xaf, 0x59, 0xda, 0x87, 0xf6, 0x69, 0x94, 0x8c, 0x91, 0x65, 0x2c, 0x4a, 0x0a,
+	0xb5, 0xac, 0x2a, 0xe5, 0x7e, 0x21, 0xd0, 0x7e, 0xb2, 0xc0, 0xd4, 0x05, 0x2b, 0x63, 0xe9, 0x39,
+	0x86, 0x85, 0x18, 0x76, 0x7b, 0xaf, 0xe5, 0x1d, 0x4b, 0xec, 0x6b, 0x03, 0xfd, 0x07, 0x3a, 0x2a,
+	0xc1, 0xb0, 0x2e, 0x91, 0x75, 0x45, 0xbf, 0x92, 0x6c, 0x6d, 0xd5, 0x46, 0x7d, 0xd5, 0x74, 0x17,
+	0xba, 0x0c, 0xf3, 0x34, 0x9e, 0x21, 0x2b, 0x4f, 0x69, 0x88, 0x53, 0x3a, 0x9a, 0xaf, 0x1c, 0x93,
+	0x17, 0x2c, 0x28, 0x70, 0x3c, 0x57, 0x43, 0x2d, 0x31, 0x97, 0x69, 0x18, 0x14, 0x41, 0x9c, 0x8e,
+	0xc5, 0x44, 0x6d, 0x5f, 0x43, 0xf7, 0x2b, 0x01, 0xf3, 0x29, 0x0b, 0xb2, 0x33, 0xba, 0x03, 0x66,
+	0x92, 0x8e, 0x50, 0xcf, 0xb1, 0xed, 0x09, 0xda, 0x3b, 0x4a, 0x47, 0xe8, 0x4b, 0x0b, 0xdd, 0x02,
+	0xe3, 0x02, 0xe7, 0x6a, 0x8a, 0xb6, 0x74, 0x78, 0x8e, 0x73, 0x9f, 0xb3, 0xbd, 0x8f, 0x04, 0x1a,
+	0xdc, 0xb9, 0x22, 0x37, 0xb2, 0x5c, 0x6e, 0xff, 0x2d, 0x95, 0x5b, 0x2d, 0x61, 0x5d, 0x72, 0x0f,
+	0x6f, 0x96, 0x5c, 0x2d, 0x72, 0xa9, 0xec, 0xdc, 0xf7, 0x04, 0x5a, 0xba, 0xdc, 0x8a, 0xbc, 0x48,
+	0x55, 0x5e, 0x55, 0x2d, 0xad, 0x5c, 0xa3, 0xa5, 0x9b, 0x76, 0xb5, 0x64, 0xe1, 0x8d, 0x65, 0x0b,
+	0x77, 0x3f, 0x1b, 0x60, 0x29, 0xb9, 0xd0, 0xfb, 0x57, 0x66, 0x21, 0x87, 0xdf, 0xd3, 0x72, 0xf2,
+	0xaa, 0x5d, 0x1c, 0x26, 0x05, 0x9b, 0x5f, 0x19, 0xcd, 0xad, 0xe2, 0x76, 0xc0, 0x8a, 0x92, 0x30,
+	0x9e, 0x8e, 0x50, 0xcc, 0xd9, 0xf6, 0x35, 0xa4, 0x7b, 0xd0, 0xca, 0x58, 0x7a, 0x1a, 0xc5, 0xe5,
+	0x20, 0x37, 0xcb, 0xb4, 0xc7, 0xca, 0x20, 0x53, 0x96, 0x7e, 0xf4, 0x0e, 0xd8, 0xe9, 0x0c, 0x19,
+	0x8b, 0xb8, 0x50, 0x4c, 0x11, 0xf4, 0x47, 0x19, 0xf4, 0x52, 0x5b, 0x64, 0xd4, 0xc2, 0x93, 0x8f,
+	0x0d, 0xdf, 0x89, 0xac, 0xb9, 0xd3, 0x14, 0x55, 0x94, 0xb8, 0xf7, 0x00, 0x7e, 0xfb, 0xa1, 0x49,
+	0xda, 0x95, 0x4a, 0x93, 0x7f, 0x32, 0xfe, 0x49, 0x37, 0xc0, 0x9c, 0x05, 0xf1, 0x14, 0xd5, 0x25,
+	0x92, 0xe0, 0xde, 0xca, 0x5d, 0xd2, 0x3b, 0x84, 0xb5, 0x5a, 0xb9, 0x4b, 0x82, 0xff, 0xaa, 0x06,
+	0xab, 0xdb, 0xca, 0x03, 0xaa, 0xc7, 0xec, 0xc3, 0x7a, 0xbd, 0x81, 0x9f, 0x29, 0xc2, 0xfd, 0x44,
+	0xc0, 0x52, 0x87, 0xde, 0xb4, 0x53, 0x6e, 0xbf, 0x75, 0xa7, 0x9b, 0xd0, 0x64, 0x38, 0x49, 0x67,
+	0x28, 0x64, 0x68, 0xfb, 0x0a, 0xfd, 0xf2, 0xa4, 0xdc, 0x37, 0xd0, 0x10, 0xff, 0xd7, 0xca, 0xab,
+	0x45, 0xea, 0xaf, 0x56, 0x17, 0x8c, 0xcb, 0x80, 0xa9, 0x48, 0xfe, 0xc9, 0x99, 0x29, 0x8b, 0xd5,
+	0x4f, 0x91, 0x7f, 0xf2, 0x65, 0x86, 0x67, 0x18, 0x5e, 0xe4, 0xd3, 0x89, 0x12, 0x78, 0x89, 0xdd,
+	0x23, 0xb0, 0x9e, 0x2d, 0x84, 0x77, 0x4d, 0x92, 0xdd, 0xab, 0xf7, 0xac, 0xe3, 0xd5, 0x9f, 0xb3,
+	0xf2, 0xbe, 0x1d, 0x98, 0xaf, 0x8d, 0x20, 0x8b, 0x4e, 0x9a, 0xe2, 0x31, 0xfe, 0xff, 0xfb, 0x00,
+	0xe8, 0x93, 0x2b, 0x9d, 0xa0, 0x07, 0x00, 0x00,
 }
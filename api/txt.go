@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// plugins.txt files do not support metadata, so lock files metadata is
+// written as header comments (e.g. "# jenkins: 2.426.3").
+const (
+	txtJenkinsHeader     = "jenkins"
+	txtFingerprintHeader = "fingerprint"
+)
+
+// MarshalTxt returns the plugins.txt representation of a plugins registry
+func (pr *PluginsRegistry) MarshalTxt() ([]byte, error) {
+	var b bytes.Buffer
+	if v := pr.GetJenkins().GetVersion(); v != "" {
+		fmt.Fprintf(&b, "# %s: %s\n", txtJenkinsHeader, v)
+	}
+	if pr.Fingerprint != "" {
+		fmt.Fprintf(&b, "# %s: %s\n", txtFingerprintHeader, pr.Fingerprint)
+	}
+	plugins := append([]*Plugin{}, pr.Plugins...)
+	sort.Sort(ByName(plugins))
+	for _, p := range plugins {
+		fmt.Fprintf(&b, "%s:%s\n", p.Name, p.Version)
+	}
+	return b.Bytes(), nil
+}
+
+// UnmarshalTxt reads a plugins registry from its plugins.txt representation
+func (pr *PluginsRegistry) UnmarshalTxt(data []byte) error {
+	*pr = PluginsRegistry{}
+	return scanTxt(data, func(name string, version string) error {
+		pr.Plugins = append(pr.Plugins, &Plugin{Name: name, Version: version})
+		return nil
+	}, func(key string, value string) {
+		switch key {
+		case txtJenkinsHeader:
+			pr.Jenkins = &Core{Version: value}
+		case txtFingerprintHeader:
+			pr.Fingerprint = value
+		}
+	})
+}
+
+// scanTxt iterates the plugins of a plugins.txt file. Comments with the
+// "# key: value" format are reported as headers.
+func scanTxt(data []byte, plugin func(string, string) error, header func(string, string)) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if kv := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, "#")), ":", 2); len(kv) == 2 {
+				header(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+			}
+			continue
+		}
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+			return errors.Errorf("line %d: malformed plugin %q, expected name:version", n, line)
+		}
+		if err := plugin(fields[0], fields[1]); err != nil {
+			return errors.Annotatef(err, "line %d", n)
+		}
+	}
+	return errors.Trace(scanner.Err())
+}
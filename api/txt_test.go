@@ -0,0 +1,66 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestPluginsRegistryTxt(t *testing.T) {
+	testCases := []struct {
+		txt     string
+		want    *PluginsRegistry
+		success bool
+	}{
+		{
+			"# jenkins: 2.426.3\n# fingerprint: abc\nbar:2.0\nfoo:1.0\n",
+			&PluginsRegistry{
+				Plugins: []*Plugin{
+					{Name: "bar", Version: "2.0"},
+					{Name: "foo", Version: "1.0"},
+				},
+				Jenkins:     &Core{Version: "2.426.3"},
+				Fingerprint: "abc",
+			},
+			true,
+		},
+		{
+			"# some comment\n\nfoo:1.0\n",
+			&PluginsRegistry{
+				Plugins: []*Plugin{{Name: "foo", Version: "1.0"}},
+			},
+			true,
+		},
+		{"foo\n", nil, false},
+		{"foo:\n", nil, false},
+	}
+	for _, tc := range testCases {
+		got := &PluginsRegistry{}
+		err := got.UnmarshalTxt([]byte(tc.txt))
+		if !tc.success {
+			if err == nil {
+				t.Errorf("not expected to read %q but it could", tc.txt)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !proto.Equal(got, tc.want) {
+			t.Errorf("wanted: %s, got: %s", tc.want, got)
+		}
+
+		// Lock files without comments must round-trip
+		data, err := got.MarshalTxt()
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		again := &PluginsRegistry{}
+		if err := again.UnmarshalTxt(data); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !proto.Equal(again, tc.want) {
+			t.Errorf("wanted: %s, got: %s", tc.want, again)
+		}
+	}
+}
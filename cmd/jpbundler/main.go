@@ -0,0 +1,175 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/oci"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/common"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/jenkinsdownloader"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jpi"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/war"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/google/renameio"
+	"github.com/juju/errors"
+)
+
+const (
+	maxWorkers = 10
+
+	formatWar = "war"
+	formatOCI = "oci"
+)
+
+var (
+	gitCommit = "UNKNOWN"
+
+	workingDir = flag.String("working-dir", filepath.Join(os.Getenv("HOME"), ".jpr"), "plugins working directory")
+	inputFile  = flag.String("input", "plugins-lock.json", "input file. You can use the output of jpresolver")
+	format     = flag.String("format", formatWar, "output format: war (a Jenkins war with the plugins embedded) or oci (an OCI image layer tarball with the plugins)")
+	warFile    = flag.String("war", "", "jenkins war file to bundle the plugins in (war format)")
+	outputFile = flag.String("output", "", "output war file (war format) or layer tarball (oci format). Layer tarballs ending with .gz or .tgz are compressed")
+	pluginsDir = flag.String("plugins-dir", "/usr/share/jenkins/ref/plugins", "plugins directory in the image filesystem (oci format)")
+	uid        = flag.Int("uid", 1000, "owner user id of the image layer files (oci format)")
+	gid        = flag.Int("gid", 1000, "owner group id of the image layer files (oci format)")
+	baseLayout = flag.String("base-layout", "", "OCI image layout directory with the base image. If provided, a new image with the plugins layer will be written to -output-layout (oci format)")
+	outLayout  = flag.String("output-layout", "", "output OCI image layout directory (oci format)")
+	platform   = flag.String("platform", "linux/amd64", "platform of the base image to use if the base layout contains several images (oci format)")
+)
+
+func readInput() (*api.PluginsRegistry, error) {
+	plugins := &api.PluginsRegistry{}
+	if err := utils.UnmarshalFile(*inputFile, plugins); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return plugins, nil
+}
+
+func run() error {
+	if err := validateFlags(); err != nil {
+		flag.Usage()
+		return errors.Trace(err)
+	}
+
+	plugins, err := readInput()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	downloader := jenkinsdownloader.NewDownloader()
+	if err := jpi.RunWorkersPoll(plugins, downloader, *workingDir, maxWorkers); err != nil {
+		return errors.Trace(err)
+	}
+
+	switch *format {
+	case formatOCI:
+		return writeImage(plugins)
+	default:
+		return war.Bundle(*warFile, plugins, *workingDir, *outputFile)
+	}
+}
+
+// layerFiles returns the list of files of the plugins layer. Plugins are named
+// after their short name (no version in the filename) as Jenkins expects.
+func layerFiles(plugins *api.PluginsRegistry) []oci.File {
+	files := []oci.File{}
+	for _, p := range plugins.Plugins {
+		files = append(files, oci.File{
+			Path:   path.Join(*pluginsDir, fmt.Sprintf("%s.jpi", p.Name)),
+			Source: jpi.GetPluginPath(p, *workingDir),
+		})
+	}
+	return files
+}
+
+func writeImage(plugins *api.PluginsRegistry) error {
+	files := layerFiles(plugins)
+	owner := oci.Owner{UID: *uid, GID: *gid}
+
+	if *outputFile != "" {
+		t, err := renameio.TempFile("", *outputFile)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer t.Cleanup()
+
+		var digest string
+		if ext := filepath.Ext(*outputFile); ext == ".gz" || ext == ".tgz" {
+			layer, err := oci.WriteCompressedLayer(t, files, owner)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			digest = layer.Digest
+		} else if digest, err = oci.WriteLayer(t, files, owner); err != nil {
+			return errors.Trace(err)
+		}
+		if err := t.CloseAtomicallyReplace(); err != nil {
+			return errors.Trace(err)
+		}
+		log.Printf("Recorded layer to disk: %s (%s)\n", *outputFile, digest)
+	}
+
+	if *baseLayout != "" {
+		createdBy := fmt.Sprintf("jpbundler -format oci -input %s (%s)", filepath.Base(*inputFile), gitCommit)
+		desc, err := oci.AppendLayer(*baseLayout, *outLayout, *platform, files, owner, createdBy)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		log.Printf("Recorded image to disk: %s (%s)\n", *outLayout, desc.Digest)
+	}
+
+	return nil
+}
+
+func validateFlags() error {
+	if *inputFile == "" {
+		return errors.Errorf("undefined input file")
+	}
+	switch *format {
+	case formatWar:
+		if *warFile == "" {
+			return errors.Errorf("undefined war file")
+		}
+		if ok, err := utils.FileExists(*warFile); err != nil {
+			return errors.Trace(err)
+		} else if !ok {
+			return errors.Errorf("%s does not exist", *warFile)
+		}
+		if *outputFile == "" {
+			return errors.Errorf("undefined output file")
+		}
+	case formatOCI:
+		if *outputFile == "" && *baseLayout == "" {
+			return errors.Errorf("undefined output file or base layout")
+		}
+		if *baseLayout != "" && *outLayout == "" {
+			return errors.Errorf("undefined output layout")
+		}
+		if !strings.HasPrefix(*pluginsDir, "/") {
+			return errors.Errorf("the plugins directory must be an absolute path: %s", *pluginsDir)
+		}
+	default:
+		return errors.Errorf("unsupported format: %s", *format)
+	}
+	if err := common.EnsureStorePathExists(*workingDir, jpi.GetStorePath); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	log.Printf("Version commit: %s\n", gitCommit)
+	if err := run(); err != nil {
+		log.Fatalf("%+v", err)
+	}
+
+	log.Printf("done!")
+}
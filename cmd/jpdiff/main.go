@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/git"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/diff"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/juju/errors"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+var (
+	gitCommit = "UNKNOWN"
+
+	oldFile = flag.String("old", "", "old lock file. If -rev is provided, it defaults to the new lock file")
+	newFile = flag.String("new", "plugins-lock.json", "new lock file")
+	rev     = flag.String("rev", "", "git revision (commit, branch, tag...) to read the old lock file from")
+	format  = flag.String("format", formatMarkdown, "output format: markdown or json")
+)
+
+// readOldLock reads the old lock file from disk or, if a revision is
+// provided, from the git repository.
+func readOldLock() (*api.PluginsRegistry, error) {
+	filename := *oldFile
+	if filename == "" {
+		filename = *newFile
+	}
+	if *rev == "" {
+		return readLock(filename)
+	}
+
+	data, err := git.Show(*rev, filename)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The lock file format depends on the file extension, so we keep it
+	f, err := ioutil.TempFile("", "*"+filepath.Ext(filename))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	lock, err := readLock(f.Name())
+	return lock, errors.Annotatef(err, "unable to read %s at revision %q", filename, *rev)
+}
+
+func readLock(filename string) (*api.PluginsRegistry, error) {
+	lock := &api.PluginsRegistry{}
+	if err := utils.UnmarshalFile(filename, lock); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return lock, nil
+}
+
+func run() error {
+	if err := validateFlags(); err != nil {
+		flag.Usage()
+		return errors.Trace(err)
+	}
+
+	old, err := readOldLock()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	new, err := readLock(*newFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	d, err := diff.Compare(old, new)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if *format == formatJSON {
+		return d.WriteJSON(os.Stdout)
+	}
+	return d.WriteMarkdown(os.Stdout)
+}
+
+func validateFlags() error {
+	if *oldFile == "" && *rev == "" {
+		return errors.Errorf("either -old or -rev must be provided")
+	}
+	if *format != formatMarkdown && *format != formatJSON {
+		return errors.Errorf("unsupported output format %q", *format)
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	log.Printf("Version commit: %s\n", gitCommit)
+	if err := run(); err != nil {
+		log.Fatalf("%+v", err)
+	}
+}
@@ -4,14 +4,20 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/bundle"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/common"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/jenkinsdownloader"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jar"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jpi"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/meta"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/war"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
 	"github.com/juju/errors"
 	"github.com/mkmik/multierror"
@@ -27,6 +33,13 @@ var (
 	workingDir = flag.String("working-dir", filepath.Join(os.Getenv("HOME"), ".jpr"), "plugins working directory, default to the HOME/.jenkins directory")
 	outputDir  = flag.String("output-dir", filepath.Join(os.Getenv("JENKINS_HOME"), "plugins"), "output directory, default to the JENKINS_HOME/plugins directory")
 	inputFile  = flag.String("input", "plugins.json.lock", "input file. You can use the output of jpresolver")
+	bundleFile = flag.String("bundle", "", "install the plugins from a bundle exported with jpresolver -export-bundle instead of downloading them. The input file is ignored")
+	warFile    = flag.String("war", "", "jenkins war file the plugins will run on. If not provided, the version will be read from the JENKINS_HOME directory (if any)")
+)
+
+const (
+	// lastExecVersionFile is written by Jenkins to its home directory with the running core version
+	lastExecVersionFile = "jenkins.install.InstallUtil.lastExecVersion"
 )
 
 func readInput() (*api.PluginsRegistry, error) {
@@ -67,16 +80,77 @@ func copyPlugins(plugins *api.PluginsRegistry) error {
 	return errs
 }
 
+// runningCoreVersion returns the version of the Jenkins core the plugins
+// will run on. It returns an empty string if it is unknown.
+func runningCoreVersion() (string, error) {
+	if *warFile != "" {
+		manifest, err := jar.ExtractManifest(*warFile)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		jm, err := war.ParseManifest(manifest)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		return jm.Version, nil
+	}
+
+	jenkinsHome := os.Getenv("JENKINS_HOME")
+	if jenkinsHome == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(filepath.Join(jenkinsHome, lastExecVersionFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// checkCore warns when the plugins were resolved for a different Jenkins core
+// than the running one.
+func checkCore(plugins *api.PluginsRegistry) error {
+	locked := plugins.GetJenkins().GetVersion()
+	if locked == "" {
+		return nil
+	}
+	running, err := runningCoreVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if running != "" && running != locked {
+		log.Printf("WARNING: the plugins were resolved for jenkins:%s but the running core is jenkins:%s\n", locked, running)
+	}
+	return nil
+}
+
 func run() error {
 	if err := validateFlags(); err != nil {
 		flag.Usage()
 		return errors.Trace(err)
 	}
 
+	// Bundles include the plugins so there is no need to download them
+	if *bundleFile != "" {
+		plugins, err := bundle.ImportFile(*bundleFile, *workingDir)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := checkCore(plugins); err != nil {
+			return errors.Trace(err)
+		}
+		return copyPlugins(plugins)
+	}
+
 	plugins, err := readInput()
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if err := checkCore(plugins); err != nil {
+		return errors.Trace(err)
+	}
 
 	downloader := jenkinsdownloader.NewDownloader()
 	if err := jpi.RunWorkersPoll(plugins, downloader, *workingDir, maxWorkers); err != nil {
@@ -87,7 +161,7 @@ func run() error {
 }
 
 func validateFlags() error {
-	if *inputFile == "" {
+	if *inputFile == "" && *bundleFile == "" {
 		return errors.Errorf("undefined input file")
 	}
 	if ok, err := utils.FileExists(*outputDir); err != nil {
@@ -95,10 +169,17 @@ func validateFlags() error {
 	} else if !ok {
 		return errors.Errorf("the output directory does not exist")
 	}
-	if err := common.EnsureStorePathExists(*workingDir, jpi.GetStorePath); err != nil {
-		return errors.Trace(err)
+	var errs error
+	for _, fn := range []func(string) string{
+		jpi.GetStorePath,
+		meta.GetStorePath,
+	} {
+		if err := common.EnsureStorePathExists(*workingDir, fn); err != nil {
+			errs = multierror.Append(errs, errors.Trace(err))
+			continue
+		}
 	}
-	return nil
+	return errs
 }
 
 func main() {
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// varsFlag is a repeatable flag of "key=value" variables. If the value is
+// omitted, it is read from the environment variable with the same name.
+type varsFlag map[string]string
+
+func (f varsFlag) String() string {
+	vars := make([]string, 0, len(f))
+	for k, v := range f {
+		vars = append(vars, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(vars)
+	return strings.Join(vars, ",")
+}
+
+func (f varsFlag) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if kv[0] == "" {
+		return errors.Errorf("malformed variable %q", s)
+	}
+	if len(kv) == 1 {
+		v, ok := os.LookupEnv(kv[0])
+		if !ok {
+			return errors.Errorf("environment variable %s is not set", kv[0])
+		}
+		kv = append(kv, v)
+	}
+	f[kv[0]] = kv[1]
+	return nil
+}
+
+// listFlag is a repeatable flag of values
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
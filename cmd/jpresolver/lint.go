@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/lint"
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+)
+
+// runLint finds the requested plugins that are redundant with the lock file of the
+// project, and it suggests a minimal project file. The minimal project file is
+// resolved too, to verify that it yields an identical lock file. Nothing is written.
+func runLint(fs *flag.FlagSet) error {
+	if err := validateLintFlags(); err != nil {
+		fs.Usage()
+		return errors.Trace(err)
+	}
+
+	project, err := readInput(*inputFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	projects, err := applyProfiles(project)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	current := projects[0]
+	baseDir := filepath.Dir(*inputFile)
+
+	locks, err := resolve([]*api.Project{current}, []string{"current project"}, baseDir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	r, err := lint.Analyze(current, locks[0], metadataReader(), *optional)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	minimal := proto.Clone(current).(*api.Project)
+	minimal.Dependencies = r.Minimal
+	minimalLocks, err := resolve([]*api.Project{minimal}, []string{"minimal project"}, baseDir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := r.Verify(locks[0], minimalLocks[0]); err != nil {
+		return errors.Trace(err)
+	}
+	if !r.Changes.Empty() {
+		log.Printf("WARNING: the minimal project file does not yield an identical lock file\n")
+	}
+
+	if *diffFormat == "json" {
+		return r.WriteJSON(os.Stdout)
+	}
+	return r.WriteMarkdown(os.Stdout)
+}
+
+func validateLintFlags() error {
+	if err := validateFlags(); err != nil {
+		return errors.Trace(err)
+	}
+	if *check || *rev != "" || *targetCore != "" || *cores != "" || *exportFile != "" || *helmOutput != "" {
+		return errors.Errorf("the lint command is not compatible with -check, -rev, -target-core, -matrix, -export-bundle nor -helm-output")
+	}
+	if len(getProfiles()) > 1 {
+		return errors.Errorf("the lint command does not support multiple profiles")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -10,14 +11,25 @@ import (
 	"strings"
 
 	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/crypto"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/git"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/bundle"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/catalog"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/common"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/diff"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/jenkinsdownloader"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/graph"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/impact"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jar"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jpi"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/matrix"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/meta"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/requesters"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/war"
+	projectfile "github.com/bitnami-labs/jenkins-plugins-resolver/pkg/project"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
 	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
 	"github.com/juju/errors"
 	"github.com/mkmik/multierror"
 )
@@ -29,13 +41,72 @@ const (
 var (
 	gitCommit = "UNKNOWN"
 
-	inputFile  = flag.String("input", "plugins.json", "input file (.json, .jsonnet. .yaml or .yml)")
-	warFile    = flag.String("war", "", "jenkins war file")
-	optional   = flag.Bool("optional", false, "add optional dependencies to the output. It will allow plugins to run with all the expected features.")
-	showGraph  = flag.Bool("show-graph", false, "show whole dependencies graph in JSON")
-	workingDir = flag.String("working-dir", filepath.Join(os.Getenv("HOME"), ".jpr"), "plugins working dir")
+	inputFile  = new(string)
+	warFile    = new(string)
+	jkVersion  = new(string)
+	jkMirror   = new(string)
+	optional   = new(bool)
+	strict     = new(bool)
+	strategy   = new(string)
+	showGraph  = new(bool)
+	workingDir = new(string)
+	outputFile = new(string)
+	outFormat  = new(string)
+	check      = new(bool)
+	cores      = new(string)
+	targetCore = new(string)
+	rev        = new(string)
+	diffFormat = new(string)
+	helm       = new(bool)
+	helmKeys   = new(string)
+	helmOutput = new(string)
+	helmOutKey = new(string)
+	profiles   = new(string)
+	failOnWarn = new(bool)
+	exportFile = new(string)
+
+	extVars = varsFlag{}
+	extCode = varsFlag{}
+	tlaVars = varsFlag{}
+	tlaCode = varsFlag{}
+	jpaths  listFlag
+
+	catalogs listFlag
 )
 
+// addFlags registers the flags shared by the resolution and the upgrade and lint commands
+func addFlags(fs *flag.FlagSet) {
+	fs.StringVar(inputFile, "input", "plugins.json", "input file (.json, .jsonnet. .yaml or .yml). plugins.txt and plugins.yaml files from the plugin installation manager are also supported")
+	fs.StringVar(warFile, "war", "", "jenkins war file")
+	fs.StringVar(jkVersion, "jenkins-version", "", "jenkins version to download the war from the mirror (alternative to -war)")
+	fs.StringVar(jkMirror, "jenkins-mirror", "https://get.jenkins.io", "jenkins wars mirror")
+	fs.BoolVar(optional, "optional", false, "add optional dependencies to the output. It will allow plugins to run with all the expected features.")
+	fs.BoolVar(strict, "strict", false, "fail if other plugins require a newer version of a requested plugin, instead of bumping it")
+	fs.StringVar(strategy, "strategy", "max", fmt.Sprintf("resolution strategy: %s. The latest-compatible strategy requires -catalog", strings.Join(graph.Strategies, ", ")))
+	fs.BoolVar(showGraph, "show-graph", false, "show whole dependencies graph in JSON")
+	fs.StringVar(workingDir, "working-dir", filepath.Join(os.Getenv("HOME"), ".jpr"), "plugins working dir")
+	fs.StringVar(outputFile, "output", "", "output lock file. It defaults to <input-file-basename>-lock.<output-format>")
+	fs.StringVar(outFormat, "output-format", "", "output lock file format: json, yaml, txt (plugins.txt) or textproto. It defaults to the -output file extension or json")
+	fs.BoolVar(check, "check", false, "check that the lock file is up to date with the project file, the jenkins core, the -optional flag and the resolver version. It fails if the lock file is out of date and it does not write anything")
+	fs.StringVar(cores, "matrix", "", "comma-separated jenkins cores (versions to download from the mirror or .war files) to resolve the project for. It reports whether the resolution succeeds for every core, the lock file changes and the incompatibilities. Nothing is written")
+	fs.StringVar(targetCore, "target-core", "", "analyze the impact of upgrading the jenkins core to the given version: plugins requiring a newer core, detached plugins changes, new implied dependencies and needed upgrades. Nothing is written")
+	fs.StringVar(rev, "rev", "", "resolve the project file as it was at the given git revision (commit, branch, tag...) and compare the result with the lock file. The revision does not need to be checked out and nothing is written")
+	fs.StringVar(diffFormat, "diff-format", "markdown", "output format of the -rev comparison, the -target-core analysis and the -matrix report: markdown or json")
+	fs.BoolVar(helm, "helm", false, "read the input file as Helm chart values, extracting the plugins from the -helm-keys lists")
+	fs.StringVar(helmKeys, "helm-keys", strings.Join(projectfile.HelmKeys, ","), "comma-separated key paths of the plugins lists in the Helm chart values")
+	fs.StringVar(helmOutput, "helm-output", "", "Helm chart values file to write the locked plugins to, at the -helm-output-key list. It is created if it does not exist")
+	fs.StringVar(helmOutKey, "helm-output-key", projectfile.HelmKeys[0], "key path of the plugins list in the -helm-output file")
+	fs.StringVar(profiles, "profile", "", "comma-separated profiles of the project file to resolve. Every profile is written to <input-file-basename>-<profile>-lock.<output-format> and all of them share a single graph fetch")
+	fs.BoolVar(failOnWarn, "fail-on-security-warnings", false, "fail if some locked plugin is affected by a security warning of the -catalog update center data, instead of reporting it")
+	fs.StringVar(exportFile, "export-bundle", "", "export a self-contained bundle (.tar.gz) with the lock file, the locked plugins and their metadata. It can be installed with jpdownloader -bundle in environments without internet access")
+	fs.Var(extVars, "ext-str", "jsonnet external variable as string: <var>=<value>, or <var> to read it from the environment. It can be repeated")
+	fs.Var(extCode, "ext-code", "jsonnet external variable as code: <var>=<code>, or <var> to read it from the environment. It can be repeated")
+	fs.Var(tlaVars, "tla-str", "jsonnet top-level argument as string: <var>=<value>, or <var> to read it from the environment. It can be repeated")
+	fs.Var(tlaCode, "tla-code", "jsonnet top-level argument as code: <var>=<code>, or <var> to read it from the environment. It can be repeated")
+	fs.Var(&jpaths, "J", "jsonnet library search path. It can be repeated")
+	fs.Var(&catalogs, "catalog", "local copy or mirror URL of the update center data (update-center.json, update-center.actual.json or plugin-versions.json). The locked plugins are checked against its deprecations and security warnings. It can be repeated")
+}
+
 // mergePlugins returns the merge of two slices of plugins with some caveats:
 //
 // The requested plugins are provided by the user and the bundled plugins are Jenkins dependencies.
@@ -93,83 +164,774 @@ func mergePlugins(requestedPlugins []*api.Plugin, bundledPlugins []*api.Plugin)
 	return plugins, nil
 }
 
-func lockPlugins(requestedPlugins []*api.Plugin, bundledPlugins []*api.Plugin) (*api.PluginsRegistry, error) {
-	plugins, err := mergePlugins(requestedPlugins, bundledPlugins)
+// lockPlugins returns the locks for several projects (e.g. one per profile), which
+// are described by names, and their incompatibilities. All of them share a single
+// graph that is fetched for every plugin.
+func lockPlugins(projects []*api.Project, names []string, bundledPlugins []*api.Plugin, jenkinsVersion string) ([]*api.PluginsRegistry, []graph.Incompatibilities, error) {
+	lists := make([][]*api.Plugin, 0, len(projects))
+	all := []*api.Plugin{}
+	for i, project := range projects {
+		plugins, err := mergePlugins(project.GetPluginsRegistry().Plugins, bundledPlugins)
+		if err != nil {
+			return nil, nil, errors.Annotate(err, names[i])
+		}
+		lists = append(lists, plugins)
+		all = appendMissingPlugins(all, plugins)
+		// The graph must include the dependencies of the overridden versions
+		all = appendMissingPlugins(all, overridePlugins(project))
+	}
+
+	c, err := readCatalog()
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
+	}
+	s, err := graph.NewStrategy(*strategy, c, jenkinsVersion)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
 	}
 
+	// Some strategies need the nodes of versions that are not required by any
+	// plugin, so the graph is fetched again including them
 	downloader := jenkinsdownloader.NewDownloader()
-	g, err := graph.FetchGraph(plugins, downloader, *workingDir, maxWorkers, *optional)
-	if err != nil {
-		return nil, errors.Trace(err)
+	extra := []*api.Plugin{}
+	for {
+		plugins := appendMissingPlugins(append([]*api.Plugin{}, all...), extra)
+		g, err := graph.FetchGraph(plugins, downloader, *workingDir, maxWorkers, *optional, jenkinsVersion)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+
+		locks, incs, err := lockProjects(g, projects, names, lists, extra, s, c)
+		if e, ok := errors.Cause(err).(*graph.MissingNodesError); ok {
+			// The nodes were already fetched, so fetching them again would not help
+			n := len(extra)
+			if extra = appendMissingPlugins(extra, e.Plugins); len(extra) == n {
+				return nil, nil, errors.Annotatef(err, "the %s strategy requested nodes that were already fetched", *strategy)
+			}
+			log.Printf("Fetching newer versions for the %s strategy...\n", *strategy)
+			continue
+		}
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+
+		if *showGraph {
+			m := jsonpb.Marshaler{Indent: "  "}
+			if err := m.Marshal(os.Stdout, g); err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+		}
+		return locks, incs, nil
 	}
+}
 
-	if *showGraph {
-		m := jsonpb.Marshaler{Indent: "  "}
-		if err := m.Marshal(os.Stdout, g); err != nil {
+// lockProjects locks the plugins lists of several projects from a shared graph.
+// The extra plugins are the nodes fetched for the strategy. The locked plugins
+// are checked against the deprecations and security warnings of the catalog (if any).
+func lockProjects(g *api.Graph, projects []*api.Project, names []string, lists [][]*api.Plugin, extra []*api.Plugin, s graph.Strategy, c *catalog.Catalog) ([]*api.PluginsRegistry, []graph.Incompatibilities, error) {
+	locks := make([]*api.PluginsRegistry, 0, len(lists))
+	allIncs := make([]graph.Incompatibilities, 0, len(lists))
+	for i, plugins := range lists {
+		project := projects[i]
+		sg := graph.SubGraph(g, append(append(overridePlugins(project), plugins...), extra...))
+		lock, err := graph.LockPluginsWithOptions(sg, &graph.Options{
+			Optional:  *optional,
+			Overrides: project.Overrides,
+			Excludes:  project.Excludes,
+			Strict:    *strict,
+			Strategy:  s,
+		})
+		if err != nil {
+			return nil, nil, errors.Annotate(err, names[i])
+		}
+
+		// Excluded plugins (e.g. bundled ones) are not locked
+		locked := []*api.Plugin{}
+		for _, p := range plugins {
+			if !excluded(project, p.Name) {
+				locked = append(locked, p)
+			}
+		}
+		incs, err := graph.FindIncompatibilities(locked, lock.Plugins, sg)
+		if err != nil {
+			return nil, nil, errors.Annotate(err, names[i])
+		}
+		if len(incs) > 0 {
+			log.Printf(" There were found some incompatibilities (%s):\n", names[i])
+			incs.Print()
+		}
+		if c != nil {
+			// Overridden plugins are migrated in the project file too
+			deps := graph.FindDeprecations(append(overridePlugins(project), locked...), lock.Plugins, sg, c)
+			if len(deps) > 0 {
+				log.Printf(" There were found some deprecated plugins (%s):\n", names[i])
+				deps.Print()
+			}
+		}
+		if err := checkSecurityWarnings(lock, c, names[i]); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		locks = append(locks, lock)
+		allIncs = append(allIncs, incs)
+	}
+	return locks, allIncs, nil
+}
+
+// checkSecurityWarnings reports the locked plugins affected by the security warnings
+// of the catalog (if any). It fails if -fail-on-security-warnings is provided.
+func checkSecurityWarnings(lock *api.PluginsRegistry, c *catalog.Catalog, name string) error {
+	if c == nil {
+		return nil
+	}
+	sws := graph.FindSecurityWarnings(lock.Plugins, c)
+	if len(sws) == 0 {
+		return nil
+	}
+	log.Printf(" There were found some security warnings (%s):\n", name)
+	sws.Print()
+	if *failOnWarn {
+		return errors.Errorf("%s: %d locked plugins are affected by security warnings", name, len(sws))
+	}
+	return nil
+}
+
+// fetchedCatalogs are the -catalog files, once the mirror URLs are downloaded
+var fetchedCatalogs []string
+
+// catalogFiles returns the -catalog files. The mirror URLs are downloaded to the
+// working directory once per run.
+func catalogFiles() ([]string, error) {
+	if fetchedCatalogs != nil {
+		return fetchedCatalogs, nil
+	}
+	files := make([]string, 0, len(catalogs))
+	for _, c := range catalogs {
+		if !strings.HasPrefix(c, "http://") && !strings.HasPrefix(c, "https://") {
+			files = append(files, c)
+			continue
+		}
+		file, err := catalog.Fetch(c, *workingDir)
+		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		files = append(files, file)
 	}
+	fetchedCatalogs = files
+	return files, nil
+}
 
-	lock, err := graph.LockPlugins(g, *optional)
+// readCatalog reads the -catalog files. It returns nil if there are none.
+func readCatalog() (*catalog.Catalog, error) {
+	if len(catalogs) == 0 {
+		return nil, nil
+	}
+	files, err := catalogFiles()
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	c, err := catalog.Read(files...)
+	return c, errors.Trace(err)
+}
 
-	incs, err := graph.FindIncompatibilities(plugins, lock.Plugins, g)
+// catalogChecksum returns the checksum of the -catalog files. It is empty if the
+// strategy does not use them.
+func catalogChecksum() (string, error) {
+	if *strategy != "latest-compatible" {
+		return "", nil
+	}
+	files, err := catalogFiles()
 	if err != nil {
-		return nil, errors.Trace(err)
+		return "", errors.Trace(err)
+	}
+	sums := make([]string, 0, len(files))
+	for _, f := range files {
+		sum, err := crypto.SHA256File(f)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		sums = append(sums, sum)
+	}
+	return strings.Join(sums, ","), nil
+}
+
+// excluded returns whether a plugin is excluded in a project
+func excluded(project *api.Project, name string) bool {
+	for _, e := range project.GetExcludes() {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// overridePlugins returns the overridden plugins of a project
+func overridePlugins(project *api.Project) []*api.Plugin {
+	plugins := []*api.Plugin{}
+	for name, version := range project.GetOverrides() {
+		plugins = append(plugins, &api.Plugin{
+			Name:      name,
+			Version:   version,
+			Requester: requesters.OVERRIDE,
+		})
+	}
+	sort.Sort(api.ByName(plugins))
+	return plugins
+}
+
+// appendMissingPlugins appends the plugins that are not in the list yet
+func appendMissingPlugins(list []*api.Plugin, plugins []*api.Plugin) []*api.Plugin {
+	for _, p := range plugins {
+		found := false
+		for _, lp := range list {
+			if proto.Equal(lp, p) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// getProfiles returns the requested profiles. The base project is the "" profile.
+func getProfiles() []string {
+	if *profiles == "" {
+		return []string{""}
+	}
+	return strings.Split(*profiles, ",")
+}
+
+func profileName(profile string) string {
+	if profile == "" {
+		return "base project"
+	}
+	return fmt.Sprintf("%s profile", profile)
+}
+
+// profileNames returns the descriptions of the requested profiles
+func profileNames() []string {
+	names := []string{}
+	for _, profile := range getProfiles() {
+		names = append(names, profileName(profile))
+	}
+	return names
+}
+
+// applyProfiles returns the projects for the requested profiles
+func applyProfiles(project *api.Project) ([]*api.Project, error) {
+	var projects []*api.Project
+	for _, profile := range getProfiles() {
+		p, err := projectfile.ApplyProfile(project, profile)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		projects = append(projects, p)
 	}
-	if len(incs) > 0 {
-		log.Printf(" There were found some incompatibilities:\n")
-		incs.Print()
+	return projects, nil
+}
+
+func readInput(filename string) (*api.Project, error) {
+	return readInputWithJPaths(filename, jpaths)
+}
+
+// readInputWithJPaths reads the project file with the given jsonnet library search paths
+func readInputWithJPaths(filename string, jpaths []string) (*api.Project, error) {
+	if *helm {
+		p, err := projectfile.ReadHelmValues(filename, strings.Split(*helmKeys, ","), versionResolver())
+		return p, errors.Trace(err)
 	}
+	p, err := projectfile.Read(filename, &projectfile.Options{
+		Resolver: versionResolver(),
+		Jsonnet:  jsonnetOptions(jpaths),
+	})
+	return p, errors.Trace(err)
+}
 
-	return lock, nil
+// versionResolver returns the resolver of plugins requested without a pinned
+// version. The plugins it downloads are stored in the working dir.
+func versionResolver() *projectfile.UpdateSiteResolver {
+	r := projectfile.NewUpdateSiteResolver()
+	r.WorkingDir = *workingDir
+	return r
 }
 
-func readInput() (*api.Project, error) {
-	project := &api.Project{}
-	if err := utils.UnmarshalFile(*inputFile, project); err != nil {
+// jsonnetOptions returns the options to evaluate Jsonnet project files with the
+// given library search paths
+func jsonnetOptions(jpaths []string) *utils.JsonnetOptions {
+	return &utils.JsonnetOptions{
+		ExtVars: extVars,
+		ExtCode: extCode,
+		TLAVars: tlaVars,
+		TLACode: tlaCode,
+		JPaths:  jpaths,
+	}
+}
+
+// readCore returns the Jenkins core the project will be resolved for (if any).
+// The command line flags take precedence over the project file. Relative paths
+// in the project file are relative to baseDir.
+func readCore(project *api.Project, baseDir string) (*api.Jenkins, error) {
+	downloader := warDownloader()
+
+	var jk *api.Jenkins
+	var err error
+	switch {
+	case *warFile != "":
+		jk, err = war.Read(*warFile, *workingDir)
+	case *jkVersion != "":
+		jk, err = war.ReadVersion(*jkVersion, downloader, *workingDir)
+	case project.Jenkins != nil:
+		return war.ReadCore(project.Jenkins, downloader, baseDir, *workingDir)
+	default:
+		return nil, nil
+	}
+	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return project, nil
+
+	if v := project.GetJenkins().GetVersion(); v != "" && v != jk.Version {
+		log.Printf("WARNING: the project file declares jenkins:%s but the plugins will be resolved for jenkins:%s\n", v, jk.Version)
+	}
+	return jk, nil
+}
+
+// warDownloader returns a downloader for the -jenkins-mirror wars
+func warDownloader() *jenkinsdownloader.Downloader {
+	downloader := jenkinsdownloader.NewDownloader()
+	downloader.WarURL = strings.TrimSuffix(*jkMirror, "/")
+	return downloader
 }
 
-func writeOutput(pr *api.PluginsRegistry) error {
-	outputFile := fmt.Sprintf("%s-lock.%s", strings.TrimSuffix(*inputFile, filepath.Ext(*inputFile)), "json")
+// getOutputFile returns the lock file of a profile ("" for the base project)
+func getOutputFile(profile string) string {
+	if *outputFile != "" {
+		return *outputFile
+	}
+	format := *outFormat
+	if format == "" {
+		format = "json"
+	}
+	base := strings.TrimSuffix(*inputFile, filepath.Ext(*inputFile))
+	if profile != "" {
+		base = fmt.Sprintf("%s-%s", base, profile)
+	}
+	return fmt.Sprintf("%s-lock.%s", base, format)
+}
+
+func writeOutput(pr *api.PluginsRegistry, profile string) error {
 	sort.Sort(api.ByName(pr.Plugins))
-	return utils.MarshalJSON(outputFile, pr)
+	return utils.MarshalFile(getOutputFile(profile), pr)
 }
 
-func run() error {
+// fingerprint returns the hash of the inputs a lock file is generated from
+func fingerprint(project *api.Project, jenkinsVersion string) (string, error) {
+	catalog, err := catalogChecksum()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	fp := &api.Fingerprint{
+		Project:         project,
+		JenkinsVersion:  jenkinsVersion,
+		Optional:        *optional,
+		ResolverVersion: gitCommit,
+		Catalog:         catalog,
+	}
+	// The default strategy keeps the fingerprints of previous lock files
+	if *strategy != "max" {
+		fp.Strategy = *strategy
+	}
+	return crypto.SHA256(fp)
+}
+
+// coreVersion returns the version of the Jenkins core the project will be
+// resolved for without downloading it. See readCore.
+func coreVersion(project *api.Project) (string, error) {
+	switch {
+	case *warFile != "":
+		manifest, err := jar.ExtractManifest(*warFile)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		jm, err := war.ParseManifest(manifest)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		return jm.Version, nil
+	case *jkVersion != "":
+		return *jkVersion, nil
+	}
+	return project.GetJenkins().GetVersion(), nil
+}
+
+// checkLock fails if the lock file of a profile was not generated from the current inputs
+func checkLock(project *api.Project, profile string) error {
+	version, err := coreVersion(project)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	want, err := fingerprint(project, version)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	outputFile := getOutputFile(profile)
+	lock := &api.PluginsRegistry{}
+	if err := utils.UnmarshalFile(outputFile, lock); err != nil {
+		return errors.Annotatef(err, "unable to read the lock file")
+	}
+	if lock.Fingerprint != want {
+		return errors.Errorf("%s is out of date (wanted fingerprint %q, got %q). Please, run jpresolver again", outputFile, want, lock.Fingerprint)
+	}
+	// Security warnings are published after the lock file is generated
+	c, err := readCatalog()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := checkSecurityWarnings(lock, c, outputFile); err != nil {
+		return errors.Trace(err)
+	}
+	log.Printf("%s is up to date\n", outputFile)
+	return nil
+}
+
+func run(fs *flag.FlagSet) error {
 	if err := validateFlags(); err != nil {
-		flag.Usage()
+		fs.Usage()
 		return errors.Trace(err)
 	}
 
-	project, err := readInput()
+	if *rev != "" {
+		return diffRevision()
+	}
+
+	project, err := readInput(*inputFile)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	plugins := project.GetPluginsRegistry()
+	projects, err := applyProfiles(project)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if *targetCore != "" {
+		return analyzeCore(projects[0], filepath.Dir(*inputFile))
+	}
+	if *cores != "" {
+		return resolveMatrix(projects[0])
+	}
+	if *check {
+		var errs error
+		for i, profile := range getProfiles() {
+			if err := checkLock(projects[i], profile); err != nil {
+				errs = multierror.Append(errs, errors.Trace(err))
+			}
+		}
+		return errs
+	}
 
-	jkpr := &api.PluginsRegistry{}
-	if *warFile != "" {
-		jk, err := war.Read(*warFile, *workingDir)
-		if err != nil {
+	locks, err := resolve(projects, profileNames(), filepath.Dir(*inputFile))
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	for i, profile := range getProfiles() {
+		if err := writeOutput(locks[i], profile); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	// The following outputs are only supported for a single profile
+	lock := locks[0]
+
+	if *helmOutput != "" {
+		if err := projectfile.WriteHelmValues(*helmOutput, *helmOutKey, strings.Split(*helmKeys, ","), lock); err != nil {
 			return errors.Trace(err)
 		}
+		log.Printf("Recorded plugins to the %s list in %s\n", *helmOutKey, *helmOutput)
+	}
+
+	if *exportFile != "" {
+		return exportBundle(lock)
+	}
+	return nil
+}
+
+// resolve returns the locks for several projects (e.g. one per profile), which are
+// described by names. Relative paths in the project file are relative to baseDir.
+func resolve(projects []*api.Project, names []string, baseDir string) ([]*api.PluginsRegistry, error) {
+	// Profiles only change the dependencies, so all of them share the same core
+	jk, err := readCore(projects[0], baseDir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	jkpr := &api.PluginsRegistry{}
+	jenkinsVersion := ""
+	if jk != nil {
 		jkpr = war.NewPluginsRegistry(jk)
+		jenkinsVersion = jk.Version
 	}
 
-	lock, err := lockPlugins(plugins.Plugins, jkpr.Plugins)
+	locks, _, err := lockPlugins(projects, names, jkpr.Plugins, jenkinsVersion)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i, lock := range locks {
+		if jk != nil {
+			lock.Jenkins = &api.Core{Version: jenkinsVersion}
+		}
+		if lock.Fingerprint, err = fingerprint(projects[i], jenkinsVersion); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return locks, nil
+}
+
+// diffRevision resolves the project file as it was at the requested revision and
+// compares the result with the lock file in the working tree. Nothing is written.
+func diffRevision() error {
+	tmpDir, err := ioutil.TempDir("", "jpresolver")
 	if err != nil {
 		return errors.Trace(err)
 	}
+	defer os.RemoveAll(tmpDir)
 
-	return writeOutput(lock)
+	input, err := filepath.Abs(*inputFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	baseDir, revJPaths, err := exportRevision(input, tmpDir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	project, err := readInputWithJPaths(filepath.Join(baseDir, filepath.Base(input)), revJPaths)
+	if err != nil {
+		return errors.Annotatef(err, "unable to read %s at revision %q", *inputFile, *rev)
+	}
+	projects, err := applyProfiles(project)
+	if err != nil {
+		return errors.Annotatef(err, "unable to read %s at revision %q", *inputFile, *rev)
+	}
+	revLocks, err := resolve(projects, profileNames(), baseDir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	revLock := revLocks[0]
+
+	lock := &api.PluginsRegistry{}
+	if err := utils.UnmarshalFile(getOutputFile(getProfiles()[0]), lock); err != nil {
+		return errors.Annotatef(err, "unable to read the lock file")
+	}
+
+	d, err := diff.Compare(revLock, lock)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if *diffFormat == "json" {
+		return d.WriteJSON(os.Stdout)
+	}
+	return d.WriteMarkdown(os.Stdout)
+}
+
+// analyzeCore resolves the project for its current core and the -target-core one,
+// and it reports the impact of the core upgrade. Nothing is written.
+func analyzeCore(project *api.Project, baseDir string) error {
+	current, err := readCore(project, baseDir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	target, err := war.ReadVersion(*targetCore, warDownloader(), *workingDir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	currentBundled := []*api.Plugin{}
+	currentVersion := ""
+	if current != nil {
+		currentBundled = war.NewPluginsRegistry(current).Plugins
+		currentVersion = current.Version
+	}
+	locks, _, err := lockPlugins([]*api.Project{project}, []string{"current core"}, currentBundled, currentVersion)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// Requested plugins older than the bundled ones must be upgraded
+	targetProject := proto.Clone(project).(*api.Project)
+	for _, pm := range target.Plugins {
+		version, ok := targetProject.Dependencies[pm.Plugin.Name]
+		if !ok {
+			continue
+		}
+		if lower, err := utils.VersionLower(version, pm.Plugin.Version); err != nil {
+			return errors.Trace(err)
+		} else if lower {
+			targetProject.Dependencies[pm.Plugin.Name] = pm.Plugin.Version
+		}
+	}
+	targetLocks, _, err := lockPlugins([]*api.Project{targetProject}, []string{"target core"}, war.NewPluginsRegistry(target).Plugins, target.Version)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	r, err := impact.Analyze(current, target, locks[0], targetLocks[0], metadataReader())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if *diffFormat == "json" {
+		return r.WriteJSON(os.Stdout)
+	}
+	return r.WriteMarkdown(os.Stdout)
+}
+
+// resolveMatrix resolves the project for every -matrix core and reports the results.
+// All of them share the working directory, so plugins metadata is fetched once.
+// Nothing is written.
+func resolveMatrix(project *api.Project) error {
+	m := &matrix.Matrix{Metadata: metadataReader()}
+	for _, core := range strings.Split(*cores, ",") {
+		lock, incs, err := lockCore(project, core)
+		if err != nil {
+			log.Printf("WARNING: %v\n", err)
+		}
+		if err := m.Add(core, lock, incs, err); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	write := m.WriteMarkdown
+	if *diffFormat == "json" {
+		write = m.WriteJSON
+	}
+	if err := write(os.Stdout); err != nil {
+		return errors.Trace(err)
+	}
+	if failed := m.Failed(); failed > 0 {
+		return errors.Errorf("the project could not be resolved for %d of %d cores", failed, len(m.Results))
+	}
+	return nil
+}
+
+// lockCore returns the lock of a project for a -matrix core and its incompatibilities
+func lockCore(project *api.Project, core string) (*api.PluginsRegistry, graph.Incompatibilities, error) {
+	var jk *api.Jenkins
+	var err error
+	if strings.HasSuffix(core, ".war") {
+		jk, err = war.Read(core, *workingDir)
+	} else {
+		jk, err = war.ReadVersion(core, warDownloader(), *workingDir)
+	}
+	if err != nil {
+		return nil, nil, errors.Annotatef(err, "unable to read jenkins:%s", core)
+	}
+
+	locks, incs, err := lockPlugins([]*api.Project{project}, []string{fmt.Sprintf("jenkins:%s", jk.Version)}, war.NewPluginsRegistry(jk).Plugins, jk.Version)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	lock := locks[0]
+	lock.Jenkins = &api.Core{Version: jk.Version}
+	sort.Sort(api.ByName(lock.Plugins))
+	return lock, incs[0], nil
+}
+
+// metadataReader returns a function that reads the metadata of a plugin from the
+// working directory, fetching it if needed
+func metadataReader() impact.MetadataFunc {
+	downloader := jenkinsdownloader.NewDownloader()
+	return func(p *api.Plugin) (*api.PluginMetadata, error) {
+		if err := meta.FetchMetadata(p, downloader, *workingDir); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return meta.ReadMetadata(meta.GetMetaPath(p, *workingDir))
+	}
+}
+
+// exportRevision exports the project file directory and the library search paths,
+// as they were at the requested revision, to the output directory. The included
+// files outside them are exported too. It returns the export of the project file
+// directory and the library search paths in the export.
+func exportRevision(input string, output string) (string, []string, error) {
+	dir := filepath.Dir(input)
+	baseDir, err := git.Export(*rev, dir, output, append([]string{dir}, jpaths...)...)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	// Library search paths in the repository are read at the revision too
+	revJPaths := make([]string, 0, len(jpaths))
+	for _, jpath := range jpaths {
+		revJPaths = append(revJPaths, revisionPath(jpath, dir, baseDir, output))
+	}
+	if *helm {
+		return baseDir, revJPaths, nil
+	}
+
+	// Every included file may include other files, so they are exported until
+	// there are no new ones
+	exported := map[string]bool{}
+	for {
+		includes, err := projectfile.Includes(filepath.Join(baseDir, filepath.Base(input)), &projectfile.Options{
+			Jsonnet: jsonnetOptions(revJPaths),
+		})
+		if err != nil {
+			return "", nil, errors.Annotatef(err, "unable to read %s at revision %q", *inputFile, *rev)
+		}
+		var missing []string
+		for _, inc := range includes {
+			rel, err := filepath.Rel(baseDir, inc)
+			if err != nil {
+				return "", nil, errors.Trace(err)
+			}
+			path := filepath.Join(dir, rel)
+			if ok, err := utils.FileExists(inc); err != nil || ok || exported[path] {
+				continue
+			}
+			exported[path] = true
+			missing = append(missing, path)
+		}
+		if len(missing) == 0 {
+			return baseDir, revJPaths, nil
+		}
+		if _, err := git.Export(*rev, dir, output, missing...); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+	}
+}
+
+// revisionPath returns the path in the exported revision for a path relative to
+// dir, where baseDir is the export of dir. Paths outside the exported root or
+// missing in the revision (e.g. untracked files) are not changed.
+func revisionPath(path, dir, baseDir, root string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return path
+	}
+	revPath := filepath.Join(baseDir, rel)
+	if !strings.HasPrefix(revPath, filepath.Clean(root)+string(filepath.Separator)) {
+		return path
+	}
+	if ok, err := utils.FileExists(revPath); err != nil || !ok {
+		return path
+	}
+	return revPath
+}
+
+func exportBundle(lock *api.PluginsRegistry) error {
+	// The locked plugins are usually in the store already (they were downloaded
+	// to compute their metadata), but we ensure none of them is missing.
+	downloader := jenkinsdownloader.NewDownloader()
+	if err := jpi.RunWorkersPoll(lock, downloader, *workingDir, maxWorkers); err != nil {
+		return errors.Trace(err)
+	}
+	if err := bundle.ExportFile(*exportFile, lock, *workingDir); err != nil {
+		return errors.Trace(err)
+	}
+	log.Printf("Recorded bundle to disk: %s\n", *exportFile)
+	return nil
 }
 
 func validateFlags() error {
@@ -178,14 +940,79 @@ func validateFlags() error {
 	} else if !ok {
 		return errors.Errorf("%s does not exist", *inputFile)
 	}
+	if *warFile != "" && *jkVersion != "" {
+		return errors.Errorf("-war and -jenkins-version are mutually exclusive")
+	}
+	if *check && *exportFile != "" {
+		return errors.Errorf("-check and -export-bundle are mutually exclusive")
+	}
+	if *rev != "" && (*check || *exportFile != "" || *helmOutput != "") {
+		return errors.Errorf("-rev is not compatible with -check, -export-bundle nor -helm-output")
+	}
+	if *targetCore != "" && (*check || *rev != "" || *exportFile != "" || *helmOutput != "" || len(getProfiles()) > 1) {
+		return errors.Errorf("-target-core is not compatible with -check, -rev, -export-bundle, -helm-output nor multiple profiles")
+	}
+	if *cores != "" && (*check || *rev != "" || *targetCore != "" || *warFile != "" || *jkVersion != "" || *exportFile != "" || *helmOutput != "" || len(getProfiles()) > 1) {
+		return errors.Errorf("-matrix is not compatible with -check, -rev, -target-core, -war, -jenkins-version, -export-bundle, -helm-output nor multiple profiles")
+	}
+	if *cores != "" {
+		for _, core := range strings.Split(*cores, ",") {
+			if core == "" || strings.TrimSpace(core) != core {
+				return errors.Errorf("malformed matrix %q", *cores)
+			}
+		}
+	}
+	if *check && *helmOutput != "" {
+		return errors.Errorf("-check and -helm-output are mutually exclusive")
+	}
+	if *profiles != "" {
+		for _, profile := range getProfiles() {
+			if profile == "" || strings.TrimSpace(profile) != profile {
+				return errors.Errorf("malformed profiles %q", *profiles)
+			}
+		}
+	}
+	if len(getProfiles()) > 1 && (*outputFile != "" || *rev != "" || *exportFile != "" || *helmOutput != "") {
+		return errors.Errorf("multiple profiles are not compatible with -output, -rev, -export-bundle nor -helm-output")
+	}
+	switch *outFormat {
+	case "", "json", "yaml", "txt", "textproto":
+	default:
+		return errors.Errorf("unsupported output format %q", *outFormat)
+	}
+	if *outputFile != "" && *outFormat != "" && filepath.Ext(*outputFile) != "."+*outFormat {
+		return errors.Errorf("the -output file extension does not match the %q output format", *outFormat)
+	}
+	if *outputFile != "" {
+		// The lock file format depends on the extension (see utils.MarshalFile)
+		switch filepath.Ext(*outputFile) {
+		case ".json", ".yaml", ".yml", ".txt", ".textproto":
+		default:
+			return errors.Errorf("unsupported -output file extension %q", filepath.Ext(*outputFile))
+		}
+	}
+	if *diffFormat != "markdown" && *diffFormat != "json" {
+		return errors.Errorf("unsupported diff format %q", *diffFormat)
+	}
+	if err := graph.ValidStrategy(*strategy); err != nil {
+		return errors.Trace(err)
+	}
+	if *strategy == "latest-compatible" && len(catalogs) == 0 {
+		return errors.Errorf("the %s strategy requires -catalog", *strategy)
+	}
+	if *failOnWarn && len(catalogs) == 0 {
+		return errors.Errorf("-fail-on-security-warnings requires -catalog")
+	}
 
 	// Ensure working paths exist
 	var errs error
 	for _, fn := range []func(string) string{
+		catalog.GetStorePath,
 		graph.GetStorePath,
 		jpi.GetStorePath,
 		meta.GetStorePath,
 		war.GetStorePath,
+		war.GetArtifactStorePath,
 	} {
 		if err := common.EnsureStorePathExists(*workingDir, fn); err != nil {
 			errs = multierror.Append(errs, errors.Trace(err))
@@ -196,10 +1023,28 @@ func validateFlags() error {
 }
 
 func main() {
-	flag.Parse()
+	// The upgrade and lint commands have their own flags on top of the shared ones
+	fs := flag.CommandLine
+	command := run
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "upgrade":
+			fs = flag.NewFlagSet("jpresolver upgrade", flag.ExitOnError)
+			addUpgradeFlags(fs)
+			command = runUpgrade
+			args = args[1:]
+		case "lint":
+			fs = flag.NewFlagSet("jpresolver lint", flag.ExitOnError)
+			command = runLint
+			args = args[1:]
+		}
+	}
+	addFlags(fs)
+	fs.Parse(args)
 
 	log.Printf("Version commit: %s\n", gitCommit)
-	if err := run(); err != nil {
+	if err := command(fs); err != nil {
 		log.Fatalf("%+v", err)
 	}
 }
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/catalog"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/diff"
+	projectfile "github.com/bitnami-labs/jenkins-plugins-resolver/pkg/project"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+)
+
+// Kinds of upgrade proposals
+const (
+	newest         = "newest"
+	sameMajor      = "same-major"
+	coreCompatible = "core-compatible"
+)
+
+var (
+	proposalKinds = []string{newest, sameMajor, coreCompatible}
+
+	upgradeWrite = new(string)
+)
+
+// addUpgradeFlags registers the flags of the upgrade command on top of the shared ones
+func addUpgradeFlags(fs *flag.FlagSet) {
+	fs.StringVar(upgradeWrite, "write", "", fmt.Sprintf("write the given proposal (%s) of every requested plugin to the project file", strings.Join(proposalKinds, ", ")))
+}
+
+// proposal is an upgrade of a requested plugin
+type proposal struct {
+	Plugin string `json:"plugin"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	// Kinds are the kinds of proposals that offer this version
+	Kinds []string `json:"kinds"`
+	// Changes are the changes of the rest of plugins in the lock file
+	Changes *diff.Diff `json:"changes,omitempty"`
+}
+
+// proposeUpgrades returns the upgrades of the requested plugins of a project that
+// are available in the catalog. The core-compatible proposals require the Jenkins
+// core version.
+func proposeUpgrades(project *api.Project, c *catalog.Catalog, jenkinsVersion string) ([]*proposal, error) {
+	names := make([]string, 0, len(project.GetDependencies()))
+	for name := range project.GetDependencies() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	proposals := []*proposal{}
+	for _, name := range names {
+		from := project.Dependencies[name]
+		latest, err := c.Latest(name, "")
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		latestInMajor, err := c.LatestInMajor(name, from)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var compatible *catalog.Release
+		if jenkinsVersion != "" {
+			if compatible, err = c.Latest(name, jenkinsVersion); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+
+		// Several kinds of proposals may offer the same version
+		versions := map[string]*proposal{}
+		for _, kr := range []struct {
+			kind    string
+			release *catalog.Release
+		}{
+			{newest, latest},
+			{sameMajor, latestInMajor},
+			{coreCompatible, compatible},
+		} {
+			if kr.release == nil {
+				continue
+			}
+			if lower, err := utils.VersionLower(from, kr.release.Version); err != nil {
+				return nil, errors.Trace(err)
+			} else if !lower {
+				continue
+			}
+			p, ok := versions[kr.release.Version]
+			if !ok {
+				p = &proposal{Plugin: name, From: from, To: kr.release.Version}
+				versions[p.To] = p
+				proposals = append(proposals, p)
+			}
+			p.Kinds = append(p.Kinds, kr.kind)
+		}
+	}
+	return proposals, nil
+}
+
+// otherChanges returns the changes of a diff that do not affect a plugin
+func otherChanges(d *diff.Diff, plugin string) *diff.Diff {
+	other := &diff.Diff{Jenkins: d.Jenkins, Plugins: []diff.Change{}}
+	for _, c := range d.Plugins {
+		if c.Name != plugin {
+			other.Plugins = append(other.Plugins, c)
+		}
+	}
+	return other
+}
+
+// writeProposals writes the upgrade proposals in the -diff-format format
+func writeProposals(w io.Writer, proposals []*proposal) error {
+	if *diffFormat == "json" {
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return errors.Trace(e.Encode(proposals))
+	}
+
+	for _, p := range proposals {
+		if _, err := fmt.Fprintf(w, "### %s %s → %s (%s)\n\n", p.Plugin, p.From, p.To, strings.Join(p.Kinds, ", ")); err != nil {
+			return errors.Trace(err)
+		}
+		if p.Changes.Empty() {
+			if _, err := io.WriteString(w, "No changes in other plugins.\n\n"); err != nil {
+				return errors.Trace(err)
+			}
+			continue
+		}
+		if err := p.Changes.WriteMarkdown(w); err != nil {
+			return errors.Trace(err)
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// runUpgrade proposes upgrades of the requested plugins. Every proposal is resolved
+// on top of the current project to show the changes of the rest of plugins.
+func runUpgrade(fs *flag.FlagSet) error {
+	if err := validateUpgradeFlags(); err != nil {
+		fs.Usage()
+		return errors.Trace(err)
+	}
+
+	project, err := readInput(*inputFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	projects, err := applyProfiles(project)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	current := projects[0]
+
+	c, err := readCatalog()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	jenkinsVersion, err := coreVersion(current)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if jenkinsVersion == "" {
+		log.Printf("WARNING: the Jenkins core is unknown, so there are no %s proposals\n", coreCompatible)
+	}
+	proposals, err := proposeUpgrades(current, c, jenkinsVersion)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(proposals) == 0 {
+		log.Printf("The requested plugins are up to date\n")
+		return nil
+	}
+
+	// All the proposals share a single graph with the current project
+	projects = []*api.Project{current}
+	names := []string{"current project"}
+	for _, p := range proposals {
+		up := proto.Clone(current).(*api.Project)
+		up.Dependencies[p.Plugin] = p.To
+		projects = append(projects, up)
+		names = append(names, fmt.Sprintf("%s:%s upgrade", p.Plugin, p.To))
+	}
+	locks, err := resolve(projects, names, filepath.Dir(*inputFile))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for i, p := range proposals {
+		d, err := diff.Compare(locks[0], locks[i+1])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		p.Changes = otherChanges(d, p.Plugin)
+	}
+	if err := writeProposals(os.Stdout, proposals); err != nil {
+		return errors.Trace(err)
+	}
+
+	if *upgradeWrite == "" {
+		return nil
+	}
+	versions := map[string]string{}
+	for _, p := range proposals {
+		for _, kind := range p.Kinds {
+			if kind == *upgradeWrite {
+				versions[p.Plugin] = p.To
+			}
+		}
+	}
+	if len(versions) == 0 {
+		log.Printf("There are no %s upgrades\n", *upgradeWrite)
+		return nil
+	}
+	if err := projectfile.SetVersions(*inputFile, versions); err != nil {
+		return errors.Trace(err)
+	}
+	log.Printf("Recorded %d %s upgrades to %s. Please, run jpresolver to lock them\n", len(versions), *upgradeWrite, *inputFile)
+	return nil
+}
+
+func validateUpgradeFlags() error {
+	if err := validateFlags(); err != nil {
+		return errors.Trace(err)
+	}
+	if len(catalogs) == 0 {
+		return errors.Errorf("the upgrade command requires -catalog")
+	}
+	if *check || *rev != "" || *exportFile != "" || *helmOutput != "" {
+		return errors.Errorf("the upgrade command is not compatible with -check, -rev, -export-bundle nor -helm-output")
+	}
+	if len(getProfiles()) > 1 {
+		return errors.Errorf("the upgrade command does not support multiple profiles")
+	}
+	if *upgradeWrite == "" {
+		return nil
+	}
+	found := false
+	for _, kind := range proposalKinds {
+		found = found || kind == *upgradeWrite
+	}
+	if !found {
+		return errors.Errorf("unknown %q proposal", *upgradeWrite)
+	}
+	if *profiles != "" || *helm {
+		return errors.Errorf("-write is not compatible with -profile nor -helm")
+	}
+	if pim, err := projectfile.IsPIMFile(*inputFile); err != nil {
+		return errors.Trace(err)
+	} else if pim {
+		return errors.Errorf("-write is not compatible with plugin installation manager files (%s)", *inputFile)
+	}
+	return nil
+}
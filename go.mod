@@ -14,7 +14,7 @@ require (
 
 // Import this version explicitly to fix CVE-2019-11254
 // See https://github.com/advisories/GHSA-wxc4-f4m6-wwqv
-require gopkg.in/yaml.v2 v2.2.8 // indirect
+require gopkg.in/yaml.v2 v2.2.8
 
 require (
 	github.com/juju/loggo v0.0.0-20190526231331-6e530bcce5d8 // indirect
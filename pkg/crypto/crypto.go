@@ -3,6 +3,8 @@ package crypto
 import (
 	"crypto/sha256"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/juju/errors"
@@ -18,3 +20,18 @@ func SHA256(pb proto.Message) (string, error) {
 	}
 	return fmt.Sprintf("%x", sha256.Sum256(b.Bytes())), nil
 }
+
+// SHA256File will return the sha256 sum of the provided file
+func SHA256File(filename string) (string, error) {
+	r, err := os.Open(filename)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", errors.Trace(err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
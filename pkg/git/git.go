@@ -0,0 +1,184 @@
+// Package git provides helpers to read files from git repositories.
+package git
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// Show returns the content of a file as it was at the given revision (e.g. a
+// commit, a branch or a tag). The file is read from the repository that contains
+// it, so the revision does not need to be checked out.
+func Show(rev string, filename string) ([]byte, error) {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	// The "./" prefix makes the path relative to the command directory
+	// instead of the repository root.
+	cmd := exec.Command("git", "show", fmt.Sprintf("%s:./%s", rev, base))
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Annotatef(err, "unable to read %s at revision %q: %s", filename, rev, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Export writes the given paths of the repository that contains dir, as they
+// were at the given revision, to the output directory. Relative paths are relative
+// to the current directory, and paths outside the repository or missing in the
+// revision are skipped. The working tree is not modified.
+//
+// It returns the path in the output directory that corresponds to dir.
+func Export(rev string, dir string, output string, paths ...string) (string, error) {
+	prefix, err := run(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	prefix = strings.TrimSpace(prefix)
+	// git archive only exports the current directory tree
+	root, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	root = strings.TrimSpace(root)
+	baseDir := filepath.Join(output, filepath.FromSlash(prefix))
+
+	pathspecs, err := revisionPaths(rev, dir, prefix, root, paths)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	// Without pathspecs, git archive would export the whole repository
+	if len(pathspecs) == 0 {
+		return baseDir, nil
+	}
+
+	cmd := exec.Command("git", append([]string{"archive", "--format=tar", rev, "--"}, pathspecs...)...)
+	cmd.Dir = root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err := extract(r, output); err != nil {
+		cmd.Wait()
+		return "", errors.Annotatef(err, "unable to export revision %q", rev)
+	}
+	if err := cmd.Wait(); err != nil {
+		return "", errors.Annotatef(err, "unable to export revision %q: %s", rev, strings.TrimSpace(stderr.String()))
+	}
+
+	return baseDir, nil
+}
+
+// revisionPaths returns the paths relative to the repository root that exist in
+// the revision, where prefix is the path of dir relative to the root.
+func revisionPaths(rev, dir, prefix, root string, paths []string) ([]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var res []string
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// Paths are mapped through dir, as the root may be a resolved symlink
+		rel, err := filepath.Rel(absDir, abs)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		spec := path.Clean(path.Join(prefix, filepath.ToSlash(rel)))
+		if spec == ".." || strings.HasPrefix(spec, "../") {
+			continue
+		}
+		object := spec
+		if object == "." {
+			object = ""
+		}
+		if _, err := run(root, "cat-file", "-e", fmt.Sprintf("%s:%s", rev, object)); err != nil {
+			if _, err := run(root, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+				return nil, errors.Annotatef(err, "unknown revision %q", rev)
+			}
+			continue
+		}
+		res = append(res, spec)
+	}
+	return res, nil
+}
+
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Annotatef(err, "git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// extract writes the entries of a tar archive to the output directory
+func extract(r io.Reader, output string) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		target := filepath.Join(output, filepath.FromSlash(h.Name))
+		if !strings.HasPrefix(target, filepath.Clean(output)+string(os.PathSeparator)) {
+			return errors.Errorf("malformed archive: %s is outside the output directory", h.Name)
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0777)
+		case tar.TypeReg:
+			err = writeFile(target, tr, os.FileMode(h.Mode))
+		case tar.TypeSymlink:
+			err = os.Symlink(h.Linkname, target)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+func writeFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+		return errors.Trace(err)
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(f.Close())
+}
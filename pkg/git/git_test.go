@@ -0,0 +1,153 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+func TestShow(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo, err := ioutil.TempDir("", "jpr")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(repo)
+
+	filename := filepath.Join(repo, "foo", "plugins.json")
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	runGit(t, repo, "init", "-q")
+	for _, content := range []string{"v1", "v2"} {
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		runGit(t, repo, "add", "-A")
+		runGit(t, repo, "commit", "-q", "-m", content)
+	}
+	// Uncommitted changes are ignored
+	if err := ioutil.WriteFile(filename, []byte("v3"), 0644); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		rev     string
+		want    string
+		success bool
+	}{
+		{"HEAD", "v2", true},
+		{"HEAD~1", "v1", true},
+		{"HEAD~2", "", false},
+	}
+	for _, tc := range testCases {
+		got, err := Show(tc.rev, filename)
+		if !tc.success {
+			if err == nil {
+				t.Errorf("not expected to read %s at %s but it could", filename, tc.rev)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("wanted: %q, got: %q", tc.want, string(got))
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo, err := ioutil.TempDir("", "jpr")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(repo)
+
+	files := map[string]string{
+		"lib/deps.libsonnet":  "{}",
+		"foo/plugins.jsonnet": "import '../lib/deps.libsonnet'",
+		"bar/plugins.json":    "{}",
+	}
+	for name, content := range files {
+		filename := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-q", "-m", "v1")
+	// Uncommitted changes are ignored
+	if err := ioutil.WriteFile(filepath.Join(repo, "lib/deps.libsonnet"), []byte("{foo: 1}"), 0644); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	output, err := ioutil.TempDir("", "jpr")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(output)
+
+	// Paths are relative to the current directory
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	lib, err := filepath.Rel(cwd, filepath.Join(repo, "lib"))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	foo := filepath.Join(repo, "foo")
+	dir, err := Export("HEAD", foo, output, foo, lib, filepath.Join(repo, "missing"), os.TempDir())
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if want := filepath.Join(output, "foo"); dir != want {
+		t.Errorf("wanted: %s, got: %s", want, dir)
+	}
+	for name, want := range files {
+		got, err := ioutil.ReadFile(filepath.Join(output, name))
+		// Only the requested paths are exported
+		if name == "bar/plugins.json" {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s: not expected to be exported but it was", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: wanted: %q, got: %q", name, want, string(got))
+		}
+	}
+
+	if _, err := Export("foo", foo, output, foo); err == nil {
+		t.Errorf("not expected to export an unknown revision but it did")
+	}
+}
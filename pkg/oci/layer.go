@@ -0,0 +1,180 @@
+package oci
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+var (
+	// All the layer entries share the same modification time so the layer
+	// digest only depends on the layer content (reproducible builds).
+	layerModTime = time.Unix(0, 0).UTC()
+)
+
+// File represents a regular file to be added to a layer
+type File struct {
+	// Path is the absolute path of the file in the image filesystem
+	Path string
+	// Source is the path of the file in the local filesystem
+	Source string
+}
+
+// Owner represents the numeric owner of the layer entries
+type Owner struct {
+	UID int
+	GID int
+}
+
+// Layer represents a written layer and its digests
+type Layer struct {
+	// Digest is the digest of the compressed layer (blob)
+	Digest string
+	// DiffID is the digest of the uncompressed layer
+	DiffID string
+	// Size is the size of the compressed layer
+	Size int64
+}
+
+// WriteLayer writes an uncompressed layer tarball with the provided files. Parent
+// directories are added too. The entries are sorted by path and they do not
+// include any timestamp or host specific information.
+//
+// Only the files and the directories containing them belong to the owner. The
+// rest of parent directories (e.g. /usr) belong to root, so stacking the layer
+// on a base image does not change their owner.
+//
+// It returns the digest of the layer.
+func WriteLayer(w io.Writer, files []File, owner Owner) (string, error) {
+	h := sha256.New()
+	tw := tar.NewWriter(io.MultiWriter(w, h))
+
+	sources := map[string]string{}
+	dirs := map[string]bool{}
+	// owned are the directories that contain files
+	owned := map[string]bool{}
+	for _, f := range files {
+		name := strings.TrimPrefix(path.Clean(f.Path), "/")
+		if _, ok := sources[name]; ok {
+			return "", errors.Errorf("duplicated layer file %s", f.Path)
+		}
+		sources[name] = f.Source
+		owned[path.Dir(name)] = true
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	names := make([]string, 0, len(sources)+len(dirs))
+	for name := range sources {
+		names = append(names, name)
+	}
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var err error
+		switch {
+		case owned[name]:
+			err = writeDir(tw, name, owner)
+		case dirs[name]:
+			err = writeDir(tw, name, Owner{})
+		default:
+			err = writeFile(tw, name, sources[name], owner)
+		}
+		if err != nil {
+			return "", errors.Annotatef(err, "unable to write %s", name)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", errors.Trace(err)
+	}
+
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
+
+// WriteCompressedLayer writes a gzip compressed layer tarball with the provided files.
+// See WriteLayer.
+func WriteCompressedLayer(w io.Writer, files []File, owner Owner) (*Layer, error) {
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(w, h)}
+
+	// The gzip header does not include any name nor timestamp by default
+	zw := gzip.NewWriter(cw)
+	diffID, err := WriteLayer(zw, files, owner)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return &Layer{
+		Digest: fmt.Sprintf("sha256:%x", h.Sum(nil)),
+		DiffID: diffID,
+		Size:   cw.n,
+	}, nil
+}
+
+func writeDir(tw *tar.Writer, name string, owner Owner) error {
+	return tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     0755,
+		Uid:      owner.UID,
+		Gid:      owner.GID,
+		ModTime:  layerModTime,
+		Format:   tar.FormatPAX,
+	})
+}
+
+func writeFile(tw *tar.Writer, name string, source string, owner Owner) error {
+	r, err := os.Open(source)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer r.Close()
+
+	stat, err := r.Stat()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     stat.Size(),
+		Mode:     0644,
+		Uid:      owner.UID,
+		Gid:      owner.GID,
+		ModTime:  layerModTime,
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return errors.Trace(err)
+	}
+
+	_, err = io.Copy(tw, r)
+	return errors.Trace(err)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
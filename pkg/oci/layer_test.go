@@ -0,0 +1,84 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, dir string, name string, content string) string {
+	filename := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return filename
+}
+
+func TestWriteLayer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oci")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []File{
+		{Path: "/usr/share/jenkins/ref/plugins/foo.jpi", Source: writeTestFile(t, dir, "foo-1.0.jpi", "foo")},
+		{Path: "/usr/share/jenkins/ref/plugins/bar.jpi", Source: writeTestFile(t, dir, "bar-1.0.jpi", "bar")},
+	}
+
+	var digests []string
+	var layer bytes.Buffer
+	for i := 0; i < 2; i++ {
+		layer.Reset()
+		digest, err := WriteLayer(&layer, files, Owner{UID: 1000, GID: 1000})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		digests = append(digests, digest)
+	}
+	if digests[0] != digests[1] {
+		t.Errorf("writing the same layer twice generated different digests: %v", digests)
+	}
+
+	var got []string
+	tr := tar.NewReader(&layer)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		// Only the plugins directory and its files belong to the owner
+		uid, gid := 0, 0
+		if strings.HasPrefix(h.Name, "usr/share/jenkins/ref/plugins/") {
+			uid, gid = 1000, 1000
+		}
+		if h.Uid != uid || h.Gid != gid || !h.ModTime.Equal(layerModTime) {
+			t.Errorf("%s: unexpected header %+v", h.Name, h)
+		}
+		if h.Typeflag == tar.TypeDir && h.Mode != 0755 {
+			t.Errorf("%s: wanted 0755 mode, got %o", h.Name, h.Mode)
+		}
+		got = append(got, h.Name)
+	}
+	want := []string{
+		"usr/",
+		"usr/share/",
+		"usr/share/jenkins/",
+		"usr/share/jenkins/ref/",
+		"usr/share/jenkins/ref/plugins/",
+		"usr/share/jenkins/ref/plugins/bar.jpi",
+		"usr/share/jenkins/ref/plugins/foo.jpi",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted: %q, got: %q", want, got)
+	}
+}
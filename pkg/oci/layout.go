@@ -0,0 +1,312 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/google/renameio"
+	"github.com/juju/errors"
+)
+
+const (
+	layoutFile    = "oci-layout"
+	indexFile     = "index.json"
+	layoutVersion = `{"imageLayoutVersion":"1.0.0"}`
+
+	mediaTypeImageIndex     = "application/vnd.oci.image.index.v1+json"
+	mediaTypeImageManifest  = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeImageLayer     = "application/vnd.oci.image.layer.v1.tar+gzip"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerLayer    = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+)
+
+// Platform represents the platform of an image
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String returns the os/arch[/variant] representation of a platform
+func (p *Platform) String() string {
+	s := fmt.Sprintf("%s/%s", p.OS, p.Architecture)
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// Descriptor represents an OCI content descriptor
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *Platform         `json:"platform,omitempty"`
+}
+
+// Index represents an OCI image index (index.json)
+type Index struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest represents an OCI image manifest
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// GetBlobPath returns the path to a blob in an image layout
+func GetBlobPath(layout string, digest string) string {
+	return filepath.Join(append([]string{layout, "blobs"}, strings.SplitN(digest, ":", 2)...)...)
+}
+
+// AppendLayer writes an OCI image layout to `output` with the image found in the `base`
+// layout plus a new layer with the provided files. If the base layout contains several
+// images, the `platform` one (os/arch[/variant]) will be used.
+//
+// The new image config history records `createdBy` as the command that created the layer.
+func AppendLayer(base string, output string, platform string, files []File, owner Owner, createdBy string) (*Descriptor, error) {
+	index := &Index{}
+	if err := readJSON(filepath.Join(base, indexFile), index); err != nil {
+		return nil, errors.Trace(err)
+	}
+	desc, err := selectManifest(base, index, platform)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	manifest := &Manifest{}
+	if err := readJSON(GetBlobPath(base, desc.Digest), manifest); err != nil {
+		return nil, errors.Trace(err)
+	}
+	config := map[string]json.RawMessage{}
+	if err := readJSON(GetBlobPath(base, manifest.Config.Digest), &config); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if err := copyBlobs(base, output); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	layer, err := writeLayerBlob(output, files, owner)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	layerMediaType := mediaTypeImageLayer
+	if desc.MediaType == mediaTypeDockerManifest {
+		layerMediaType = mediaTypeDockerLayer
+	}
+	manifest.Layers = append(manifest.Layers, Descriptor{
+		MediaType: layerMediaType,
+		Digest:    layer.Digest,
+		Size:      layer.Size,
+	})
+
+	if err := appendConfigLayer(config, layer, createdBy); err != nil {
+		return nil, errors.Trace(err)
+	}
+	configDesc, err := writeJSONBlob(output, manifest.Config.MediaType, config)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	manifest.Config = *configDesc
+
+	manifestDesc, err := writeJSONBlob(output, desc.MediaType, manifest)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	manifestDesc.Annotations = desc.Annotations
+	manifestDesc.Platform = desc.Platform
+
+	if err := renameio.WriteFile(filepath.Join(output, layoutFile), []byte(layoutVersion), 0644); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := writeJSON(filepath.Join(output, indexFile), &Index{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeImageIndex,
+		Manifests:     []Descriptor{*manifestDesc},
+	}); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return manifestDesc, nil
+}
+
+// selectManifest returns the image manifest descriptor from an index. Nested
+// indexes (multi-platform images) are walked through.
+func selectManifest(layout string, index *Index, platform string) (*Descriptor, error) {
+	var candidates []Descriptor
+	for _, d := range index.Manifests {
+		if platform != "" && d.Platform != nil && d.Platform.String() != platform {
+			continue
+		}
+		candidates = append(candidates, d)
+	}
+	if len(candidates) != 1 {
+		return nil, errors.Errorf("unable to select an image from the layout: found %d candidates for the %q platform", len(candidates), platform)
+	}
+
+	desc := candidates[0]
+	switch desc.MediaType {
+	case mediaTypeImageManifest, mediaTypeDockerManifest:
+		return &desc, nil
+	case mediaTypeImageIndex, mediaTypeDockerList:
+		nested := &Index{}
+		if err := readJSON(GetBlobPath(layout, desc.Digest), nested); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return selectManifest(layout, nested, platform)
+	}
+	return nil, errors.Errorf("unsupported media type %s", desc.MediaType)
+}
+
+// appendConfigLayer registers a new layer in the image config. Any other field
+// of the config is kept as it is.
+func appendConfigLayer(config map[string]json.RawMessage, layer *Layer, createdBy string) error {
+	rootfs := struct {
+		Type    string   `json:"type"`
+		DiffIDs []string `json:"diff_ids"`
+	}{}
+	if err := json.Unmarshal(config["rootfs"], &rootfs); err != nil {
+		return errors.Annotatef(err, "unable to read the image config rootfs")
+	}
+	rootfs.DiffIDs = append(rootfs.DiffIDs, layer.DiffID)
+
+	var history []map[string]interface{}
+	if raw, ok := config["history"]; ok {
+		if err := json.Unmarshal(raw, &history); err != nil {
+			return errors.Annotatef(err, "unable to read the image config history")
+		}
+	}
+	history = append(history, map[string]interface{}{
+		"created":    layerModTime.Format("2006-01-02T15:04:05Z"),
+		"created_by": createdBy,
+	})
+
+	var err error
+	if config["rootfs"], err = json.Marshal(rootfs); err != nil {
+		return errors.Trace(err)
+	}
+	if config["history"], err = json.Marshal(history); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func writeLayerBlob(layout string, files []File, owner Owner) (*Layer, error) {
+	dir := filepath.Join(layout, "blobs", "sha256")
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	t, err := ioutil.TempFile(dir, "layer")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer os.Remove(t.Name())
+	defer t.Close()
+
+	layer, err := WriteCompressedLayer(t, files, owner)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := t.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := os.Rename(t.Name(), GetBlobPath(layout, layer.Digest)); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return layer, nil
+}
+
+func writeJSONBlob(layout string, mediaType string, v interface{}) (*Descriptor, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	desc := &Descriptor{
+		MediaType: mediaType,
+		Digest:    fmt.Sprintf("sha256:%x", sha256.Sum256(data)),
+		Size:      int64(len(data)),
+	}
+	blobPath := GetBlobPath(layout, desc.Digest)
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0777); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return desc, renameio.WriteFile(blobPath, data, 0644)
+}
+
+// copyBlobs copies the blobs from a layout to another one. Blobs are content
+// addressable so existing ones are skipped.
+func copyBlobs(src string, dst string) error {
+	srcDir := filepath.Join(src, "blobs")
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Trace(err)
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		target := filepath.Join(dst, "blobs", rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0777)
+		}
+		if ok, err := utils.FileExists(target); err != nil {
+			return errors.Trace(err)
+		} else if ok {
+			return nil
+		}
+		return copyFile(target, path)
+	})
+}
+
+func copyFile(dst string, src string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer r.Close()
+
+	t, err := renameio.TempFile("", dst)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer t.Cleanup()
+
+	if _, err := io.Copy(t, r); err != nil {
+		return errors.Trace(err)
+	}
+	return t.CloseAtomicallyReplace()
+}
+
+func readJSON(filename string, v interface{}) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(json.Unmarshal(data, v), "unable to parse %s", filename)
+}
+
+func writeJSON(filename string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return renameio.WriteFile(filename, data, 0644)
+}
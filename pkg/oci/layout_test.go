@@ -0,0 +1,86 @@
+package oci
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendLayer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oci")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Create a minimal base layout with an image without layers
+	base := filepath.Join(dir, "base")
+	config, err := writeJSONBlob(base, "application/vnd.oci.image.config.v1+json", map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"rootfs":       map[string]interface{}{"type": "layers", "diff_ids": []string{}},
+	})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	manifest, err := writeJSONBlob(base, mediaTypeImageManifest, &Manifest{SchemaVersion: 2, Config: *config})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	manifest.Annotations = map[string]string{"org.opencontainers.image.ref.name": "latest"}
+	if err := writeJSON(filepath.Join(base, indexFile), &Index{SchemaVersion: 2, Manifests: []Descriptor{*manifest}}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	files := []File{
+		{Path: "/usr/share/jenkins/ref/plugins/foo.jpi", Source: writeTestFile(t, dir, "foo-1.0.jpi", "foo")},
+	}
+
+	var descs []*Descriptor
+	for _, output := range []string{"output1", "output2"} {
+		desc, err := AppendLayer(base, filepath.Join(dir, output), "", files, Owner{}, "jpbundler")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		descs = append(descs, desc)
+	}
+	if descs[0].Digest != descs[1].Digest {
+		t.Errorf("appending the same layer twice generated different images: %s, %s", descs[0].Digest, descs[1].Digest)
+	}
+	if descs[0].Annotations["org.opencontainers.image.ref.name"] != "latest" {
+		t.Errorf("the base image annotations were not kept: %v", descs[0].Annotations)
+	}
+
+	output := filepath.Join(dir, "output1")
+	got := &Manifest{}
+	if err := readJSON(GetBlobPath(output, descs[0].Digest), got); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(got.Layers) != 1 || got.Layers[0].MediaType != mediaTypeImageLayer {
+		t.Fatalf("wanted a single %s layer, got: %+v", mediaTypeImageLayer, got.Layers)
+	}
+	gotConfig := struct {
+		OS     string `json:"os"`
+		RootFS struct {
+			DiffIDs []string `json:"diff_ids"`
+		} `json:"rootfs"`
+	}{}
+	data, err := ioutil.ReadFile(GetBlobPath(output, got.Config.Digest))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := json.Unmarshal(data, &gotConfig); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if gotConfig.OS != "linux" || len(gotConfig.RootFS.DiffIDs) != 1 {
+		t.Errorf("unexpected image config: %s", string(data))
+	}
+	// The base blobs must be available in the output layout
+	for _, digest := range []string{got.Layers[0].Digest, manifest.Digest, config.Digest} {
+		if _, err := os.Stat(GetBlobPath(output, digest)); err != nil {
+			t.Errorf("%+v", err)
+		}
+	}
+}
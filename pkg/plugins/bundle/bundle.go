@@ -0,0 +1,283 @@
+// Package bundle provides a self-contained archive format to move a lock file and
+// its plugins to environments without internet access (air-gapped environments).
+//
+// A bundle is a tar.gz archive with the following layout:
+//
+//	SHA256SUMS                    checksums of the rest of files (sha256sum format)
+//	lock.json                     the lock file
+//	jpi/<name>-<version>.jpi      the locked plugins
+//	meta/<name>-<version>.meta    the locked plugins metadata
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/crypto"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jpi"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/meta"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/google/renameio"
+	"github.com/juju/errors"
+)
+
+const (
+	checksumsFile = "SHA256SUMS"
+	lockFile      = "lock.json"
+)
+
+var (
+	// All the bundle entries share the same modification time so the
+	// bundle only depends on its content.
+	bundleModTime = time.Unix(0, 0).UTC()
+)
+
+// Export writes a bundle with the lock file, the locked plugins and their metadata
+// (if present) from the working directory stores.
+func Export(w io.Writer, lock *api.PluginsRegistry, workingDir string) error {
+	m := &jsonpb.Marshaler{Indent: "  "}
+	lockData, err := m.MarshalToString(lock)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// Map bundle entries to the files in the working directory
+	files := map[string]string{}
+	for _, p := range lock.Plugins {
+		jpiPath := jpi.GetPluginPath(p, workingDir)
+		if ok, err := utils.FileExists(jpiPath); err != nil {
+			return errors.Trace(err)
+		} else if !ok {
+			return errors.Errorf("unable to find %s in the jpi store", p.Identifier())
+		}
+		files[path.Join("jpi", filepath.Base(jpiPath))] = jpiPath
+
+		metaPath := meta.GetMetaPath(p, workingDir)
+		if ok, err := utils.FileExists(metaPath); err != nil {
+			return errors.Trace(err)
+		} else if ok {
+			files[path.Join("meta", filepath.Base(metaPath))] = metaPath
+		}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Compute the checksums first, as they go at the beginning of the bundle
+	var checksums bytes.Buffer
+	fmt.Fprintf(&checksums, "%x  %s\n", sha256.Sum256([]byte(lockData)), lockFile)
+	for _, name := range names {
+		sum, err := crypto.SHA256File(files[name])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		fmt.Fprintf(&checksums, "%s  %s\n", sum, name)
+	}
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	if err := writeEntry(tw, checksumsFile, int64(checksums.Len()), &checksums); err != nil {
+		return errors.Trace(err)
+	}
+	if err := writeEntry(tw, lockFile, int64(len(lockData)), strings.NewReader(lockData)); err != nil {
+		return errors.Trace(err)
+	}
+	for _, name := range names {
+		if err := writeFileEntry(tw, name, files[name]); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(zw.Close())
+}
+
+// ExportFile writes a bundle to a file. See Export.
+func ExportFile(filename string, lock *api.PluginsRegistry, workingDir string) error {
+	t, err := renameio.TempFile("", filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer t.Cleanup()
+
+	if err := Export(t, lock, workingDir); err != nil {
+		return errors.Trace(err)
+	}
+	return t.CloseAtomicallyReplace()
+}
+
+// Import reads a bundle, verifies its checksums and installs the plugins and their
+// metadata in the working directory stores. It returns the bundled lock file.
+func Import(r io.Reader, workingDir string) (*api.PluginsRegistry, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tr := tar.NewReader(zr)
+
+	var checksums map[string]string
+	var lock *api.PluginsRegistry
+	verified := map[string]bool{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if checksums == nil {
+			if h.Name != checksumsFile {
+				return nil, errors.Errorf("malformed bundle: %s must be the first entry, found %s", checksumsFile, h.Name)
+			}
+			if checksums, err = parseChecksums(tr); err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+
+		want, ok := checksums[h.Name]
+		if !ok {
+			return nil, errors.Errorf("malformed bundle: %s is not listed in %s", h.Name, checksumsFile)
+		}
+
+		switch dir := path.Dir(h.Name); {
+		case h.Name == lockFile:
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if got := fmt.Sprintf("%x", sha256.Sum256(data)); got != want {
+				return nil, errors.Errorf("checksum mismatch for %s: wanted %s, got %s", h.Name, want, got)
+			}
+			lock = &api.PluginsRegistry{}
+			if err := jsonpb.Unmarshal(bytes.NewReader(data), lock); err != nil {
+				return nil, errors.Annotatef(err, "unable to read %s", h.Name)
+			}
+		case dir == "jpi" || dir == "meta":
+			storePath := jpi.GetStorePath(workingDir)
+			if dir == "meta" {
+				storePath = meta.GetStorePath(workingDir)
+			}
+			if err := installEntry(tr, filepath.Join(storePath, path.Base(h.Name)), want); err != nil {
+				return nil, errors.Annotatef(err, "unable to install %s", h.Name)
+			}
+		default:
+			return nil, errors.Errorf("malformed bundle: unexpected entry %s", h.Name)
+		}
+		verified[h.Name] = true
+	}
+
+	if lock == nil {
+		return nil, errors.Errorf("malformed bundle: missing %s", lockFile)
+	}
+	for name := range checksums {
+		if !verified[name] {
+			return nil, errors.Errorf("malformed bundle: missing %s", name)
+		}
+	}
+	for _, p := range lock.Plugins {
+		if !verified[path.Join("jpi", filepath.Base(jpi.GetPluginPath(p, workingDir)))] {
+			return nil, errors.Errorf("malformed bundle: missing the %s plugin", p.Identifier())
+		}
+	}
+
+	return lock, nil
+}
+
+// ImportFile reads a bundle from a file. See Import.
+func ImportFile(filename string, workingDir string) (*api.PluginsRegistry, error) {
+	r, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer r.Close()
+
+	lock, err := Import(r, workingDir)
+	return lock, errors.Annotatef(err, "unable to import %s", filename)
+}
+
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, errors.Errorf("malformed %s line: %q", checksumsFile, scanner.Text())
+		}
+		checksums[fields[1]] = fields[0]
+	}
+	return checksums, errors.Trace(scanner.Err())
+}
+
+// installEntry writes the current entry to the target file. The file is only
+// written if the checksum matches the expected one.
+func installEntry(r io.Reader, target string, checksum string) error {
+	t, err := renameio.TempFile("", target)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer t.Cleanup()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(t, h), r); err != nil {
+		return errors.Trace(err)
+	}
+	if got := fmt.Sprintf("%x", h.Sum(nil)); got != checksum {
+		return errors.Errorf("checksum mismatch: wanted %s, got %s", checksum, got)
+	}
+	return t.CloseAtomicallyReplace()
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  bundleModTime,
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return errors.Trace(err)
+	}
+	_, err := io.Copy(tw, r)
+	return errors.Trace(err)
+}
+
+func writeFileEntry(tw *tar.Writer, name string, filename string) error {
+	r, err := os.Open(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer r.Close()
+
+	stat, err := r.Stat()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return writeEntry(tw, name, stat.Size(), r)
+}
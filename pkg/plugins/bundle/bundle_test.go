@@ -0,0 +1,142 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/common"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jpi"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/meta"
+	"github.com/golang/protobuf/proto"
+)
+
+func newWorkingDir(t *testing.T) string {
+	workingDir, err := ioutil.TempDir("", "jpr")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, fn := range []func(string) string{jpi.GetStorePath, meta.GetStorePath} {
+		if err := common.EnsureStorePathExists(workingDir, fn); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	return workingDir
+}
+
+func TestExportImport(t *testing.T) {
+	src := newWorkingDir(t)
+	defer os.RemoveAll(src)
+	dst := newWorkingDir(t)
+	defer os.RemoveAll(dst)
+
+	lock := &api.PluginsRegistry{
+		Plugins: []*api.Plugin{
+			{Name: "credentials", Version: "2.2.0"},
+			{Name: "structs", Version: "1.7"},
+		},
+	}
+	for _, p := range lock.Plugins {
+		if err := ioutil.WriteFile(jpi.GetPluginPath(p, src), []byte(p.Identifier()), 0644); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	// Only some plugins have metadata
+	if err := meta.WriteMetadata(&api.PluginMetadata{Plugin: lock.Plugins[0]}, meta.GetMetaPath(lock.Plugins[0], src)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var bundles []*bytes.Buffer
+	for i := 0; i < 2; i++ {
+		var b bytes.Buffer
+		if err := Export(&b, lock, src); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		bundles = append(bundles, &b)
+	}
+	if !bytes.Equal(bundles[0].Bytes(), bundles[1].Bytes()) {
+		t.Errorf("exporting the same lock twice generated different bundles")
+	}
+
+	got, err := Import(bundles[0], dst)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !proto.Equal(got, lock) {
+		t.Errorf("wanted: %s, got: %s", lock, got)
+	}
+	for _, p := range lock.Plugins {
+		data, err := ioutil.ReadFile(jpi.GetPluginPath(p, dst))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if string(data) != p.Identifier() {
+			t.Errorf("wanted: %q, got: %q", p.Identifier(), string(data))
+		}
+	}
+	if _, err := meta.ReadMetadata(meta.GetMetaPath(lock.Plugins[0], dst)); err != nil {
+		t.Errorf("%+v", err)
+	}
+}
+
+func writeTestBundle(t *testing.T, entries [][2]string) *bytes.Buffer {
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(zw)
+	for _, e := range entries {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: e[0], Size: int64(len(e[1])), Mode: 0644}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := tw.Write([]byte(e[1])); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return &b
+}
+
+func TestImportMalformed(t *testing.T) {
+	lock := `{"plugins": [{"name": "foo", "version": "1.0"}]}`
+	// $ echo -n '{"plugins": [{"name": "foo", "version": "1.0"}]}' | sha256sum
+	lockSum := "d096b44b649afcc65b2c9101d7a1f0dfa82488580e08f7cac9eed26109dc47bb"
+
+	testCases := []struct {
+		name    string
+		entries [][2]string
+	}{
+		{"missing checksums", [][2]string{{"lock.json", lock}}},
+		{"checksum mismatch", [][2]string{
+			{"SHA256SUMS", lockSum + "  lock.json\n" + lockSum + "  jpi/foo-1.0.jpi\n"},
+			{"lock.json", lock},
+			{"jpi/foo-1.0.jpi", "foo"},
+		}},
+		{"unlisted entry", [][2]string{
+			{"SHA256SUMS", lockSum + "  lock.json\n"},
+			{"lock.json", lock},
+			{"jpi/foo-1.0.jpi", "foo"},
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := newWorkingDir(t)
+			defer os.RemoveAll(dst)
+
+			if _, err := Import(writeTestBundle(t, tc.entries), dst); err == nil {
+				t.Errorf("expected to fail importing the bundle but it did not")
+			}
+			if _, err := os.Stat(filepath.Join(jpi.GetStorePath(dst), "foo-1.0.jpi")); err == nil {
+				t.Errorf("not expected to install foo-1.0.jpi but it did")
+			}
+		})
+	}
+}
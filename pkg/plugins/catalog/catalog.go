@@ -0,0 +1,220 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/juju/errors"
+)
+
+// jsonpPrefix and jsonpSuffix wrap the update-center.json file (JSONP)
+var (
+	jsonpPrefix = []byte("updateCenter.post(")
+	jsonpSuffix = []byte(");")
+)
+
+// Dependency is a dependency of a plugin release
+type Dependency struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Optional bool   `json:"optional"`
+}
+
+// Release is a version of a plugin available in the update center
+type Release struct {
+	Name         string        `json:"name"`
+	Version      string        `json:"version"`
+	RequiredCore string        `json:"requiredCore"`
+	Dependencies []*Dependency `json:"dependencies"`
+}
+
+// Compatible returns whether the release can be installed in a Jenkins core.
+// Every release is compatible with an unknown ("") core.
+func (r *Release) Compatible(jenkinsVersion string) (bool, error) {
+	if jenkinsVersion == "" || r.RequiredCore == "" {
+		return true, nil
+	}
+	lower, err := utils.VersionLower(jenkinsVersion, r.RequiredCore)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return !lower, nil
+}
+
+// Catalog is a local copy of the Jenkins update center data
+type Catalog struct {
+	// plugins are the releases of every plugin, indexed by name and version
+	plugins map[string]map[string]*Release
+	// warnings are the security warnings of every plugin, indexed by name
+	warnings map[string][]*Warning
+	// deprecations are the deprecated plugins, indexed by name
+	deprecations map[string]*Deprecation
+}
+
+// updateCenter is the common schema of the update center files. The plugins of
+// update-center.json are releases, while the ones of plugin-versions.json are
+// maps of releases indexed by version. Only update-center.json includes warnings
+// and deprecations.
+type updateCenter struct {
+	Plugins      map[string]json.RawMessage `json:"plugins"`
+	Warnings     []*Warning                 `json:"warnings"`
+	Deprecations map[string]*Deprecation    `json:"deprecations"`
+}
+
+// Read reads a catalog from update center files (update-center.json,
+// update-center.actual.json or plugin-versions.json). The releases of all the
+// files are merged.
+func Read(filenames ...string) (*Catalog, error) {
+	c := &Catalog{plugins: map[string]map[string]*Release{}}
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if err := c.add(data); err != nil {
+			return nil, errors.Annotatef(err, "unable to read %s", filename)
+		}
+	}
+	return c, nil
+}
+
+func (c *Catalog) add(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, jsonpPrefix) {
+		data = bytes.TrimSuffix(bytes.TrimPrefix(data, jsonpPrefix), jsonpSuffix)
+	}
+
+	uc := updateCenter{}
+	if err := json.Unmarshal(data, &uc); err != nil {
+		return errors.Trace(err)
+	}
+	for name, raw := range uc.Plugins {
+		releases, err := parseReleases(raw)
+		if err != nil {
+			return errors.Annotatef(err, "malformed %s plugin", name)
+		}
+		for _, r := range releases {
+			if r.Name == "" {
+				r.Name = name
+			}
+			c.addRelease(r)
+		}
+	}
+	for _, w := range uc.Warnings {
+		// Core warnings do not affect plugins
+		if w.Type != "plugin" {
+			continue
+		}
+		if err := w.compile(); err != nil {
+			return errors.Annotatef(err, "malformed %s warning", w.ID)
+		}
+		c.addWarning(w)
+	}
+	for name, d := range uc.Deprecations {
+		d.Name = name
+		c.addDeprecation(d)
+	}
+	return nil
+}
+
+// parseReleases parses a plugin entry, which may be a release or a map of releases
+func parseReleases(raw json.RawMessage) ([]*Release, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if v, ok := fields["version"]; ok && bytes.HasPrefix(bytes.TrimSpace(v), []byte(`"`)) {
+		r := &Release{}
+		return []*Release{r}, errors.Trace(json.Unmarshal(raw, r))
+	}
+
+	releases := []*Release{}
+	for version, raw := range fields {
+		r := &Release{}
+		if err := json.Unmarshal(raw, r); err != nil {
+			return nil, errors.Annotate(err, version)
+		}
+		if r.Version == "" {
+			r.Version = version
+		}
+		releases = append(releases, r)
+	}
+	return releases, nil
+}
+
+// addRelease adds a release to the catalog. The first file providing a release wins.
+func (c *Catalog) addRelease(r *Release) {
+	releases, ok := c.plugins[r.Name]
+	if !ok {
+		releases = map[string]*Release{}
+		c.plugins[r.Name] = releases
+	}
+	if _, ok := releases[r.Version]; !ok {
+		releases[r.Version] = r
+	}
+}
+
+// Release returns a release of a plugin (nil if it is not in the catalog)
+func (c *Catalog) Release(name string, version string) *Release {
+	return c.plugins[name][version]
+}
+
+// Releases returns the releases of a plugin, sorted from the oldest to the newest
+func (c *Catalog) Releases(name string) ([]*Release, error) {
+	releases := make([]*Release, 0, len(c.plugins[name]))
+	for _, r := range c.plugins[name] {
+		releases = append(releases, r)
+	}
+
+	var errs error
+	sort.Slice(releases, func(i, j int) bool {
+		lower, err := utils.VersionLower(releases[i].Version, releases[j].Version)
+		if err != nil && errs == nil {
+			errs = err
+		}
+		return lower
+	})
+	if errs != nil {
+		return nil, errors.Annotatef(errs, "unable to sort the %s releases", name)
+	}
+	return releases, nil
+}
+
+// Newest returns the newest release of a plugin that is accepted by the filter
+// (nil if there is none)
+func (c *Catalog) Newest(name string, accept func(*Release) (bool, error)) (*Release, error) {
+	releases, err := c.Releases(name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i := len(releases) - 1; i >= 0; i-- {
+		ok, err := accept(releases[i])
+		if err != nil {
+			return nil, errors.Annotatef(err, "%s:%s", name, releases[i].Version)
+		}
+		if ok {
+			return releases[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Latest returns the newest release of a plugin that is compatible with a Jenkins
+// core (nil if there is none). Any release is compatible with an unknown ("") core.
+func (c *Catalog) Latest(name string, jenkinsVersion string) (*Release, error) {
+	return c.Newest(name, func(r *Release) (bool, error) {
+		return r.Compatible(jenkinsVersion)
+	})
+}
+
+// LatestInMajor returns the newest release of a plugin with the same major version
+// (nil if there is none)
+func (c *Catalog) LatestInMajor(name string, version string) (*Release, error) {
+	major := utils.MajorVersion(version)
+	return c.Newest(name, func(r *Release) (bool, error) {
+		return utils.MajorVersion(r.Version) == major, nil
+	})
+}
@@ -0,0 +1,171 @@
+package catalog
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	c, err := Read("testdata/update-center.json", "testdata/plugin-versions.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		versions []string
+	}{
+		{"foo", []string{"1.0", "1.9", "1.10", "2.0"}},
+		{"bar", []string{"2.0", "3.0"}},
+		{"qux", []string{}},
+	}
+	for _, tc := range testCases {
+		releases, err := c.Releases(tc.name)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		versions := []string{}
+		for _, r := range releases {
+			versions = append(versions, r.Version)
+		}
+		if !reflect.DeepEqual(versions, tc.versions) {
+			t.Errorf("%s: wanted: %v, got: %v", tc.name, tc.versions, versions)
+		}
+	}
+
+	// The first file providing a release wins
+	r := c.Release("bar", "3.0")
+	if r == nil || len(r.Dependencies) != 1 {
+		t.Errorf("wanted the bar:3.0 release of update-center.json, got: %+v", r)
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	if _, err := Read("testdata/missing.json"); err == nil {
+		t.Errorf("wanted an error reading a missing file")
+	}
+	c := &Catalog{plugins: map[string]map[string]*Release{}}
+	if err := c.add([]byte(`{"plugins": {"foo": "1.0"}}`)); err == nil {
+		t.Errorf("wanted an error reading a malformed plugin")
+	}
+}
+
+func TestLatest(t *testing.T) {
+	c, err := Read("testdata/update-center.json", "testdata/plugin-versions.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		jenkinsVersion string
+		version        string
+	}{
+		{"foo", "", "2.0"},
+		{"foo", "2.440", "2.0"},
+		{"foo", "2.426.3", "1.10"},
+		{"foo", "2.401", "1.9"},
+		{"foo", "2.300", ""},
+		{"qux", "2.440", ""},
+	}
+	for _, tc := range testCases {
+		r, err := c.Latest(tc.name, tc.jenkinsVersion)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		version := ""
+		if r != nil {
+			version = r.Version
+		}
+		if version != tc.version {
+			t.Errorf("%s (%s): wanted: %q, got: %q", tc.name, tc.jenkinsVersion, tc.version, version)
+		}
+	}
+}
+
+func TestLatestInMajor(t *testing.T) {
+	c, err := Read("testdata/update-center.json", "testdata/plugin-versions.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		version string
+		latest  string
+	}{
+		{"foo", "1.0", "1.10"},
+		{"foo", "2.0", "2.0"},
+		{"foo", "3.0", ""},
+		{"bar", "2.0", "2.0"},
+	}
+	for _, tc := range testCases {
+		r, err := c.LatestInMajor(tc.name, tc.version)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		latest := ""
+		if r != nil {
+			latest = r.Version
+		}
+		if latest != tc.latest {
+			t.Errorf("%s:%s: wanted: %q, got: %q", tc.name, tc.version, tc.latest, latest)
+		}
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	c, err := Read("testdata/update-center.json", "testdata/plugin-versions.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		version  string
+		warnings []string
+	}{
+		{"foo", "1.0", []string{"SECURITY-100 (unknown)"}},
+		// Patterns must match the whole version
+		{"foo", "1.10", []string{"SECURITY-200 (critical)"}},
+		{"foo", "1.9", []string{"SECURITY-100 (unknown)", "SECURITY-200 (critical)"}},
+		{"foo", "2.0", []string{}},
+		// Core warnings do not affect plugins
+		{"core", "2.440", []string{}},
+	}
+	for _, tc := range testCases {
+		warnings := []string{}
+		for _, w := range c.Warnings(tc.name, tc.version) {
+			warnings = append(warnings, fmt.Sprintf("%s (%s)", w.ID, w.Severity))
+		}
+		if !reflect.DeepEqual(warnings, tc.warnings) {
+			t.Errorf("%s:%s: wanted: %v, got: %v", tc.name, tc.version, tc.warnings, warnings)
+		}
+	}
+
+	if err := c.add([]byte(`{"warnings": [{"id": "SECURITY-1", "name": "foo", "type": "plugin", "versions": [{"pattern": "1[.("}]}]}`)); err == nil {
+		t.Errorf("wanted an error reading a malformed warning pattern")
+	}
+}
+
+func TestDeprecation(t *testing.T) {
+	c, err := Read("testdata/update-center.json", "testdata/plugin-versions.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		deprecation *Deprecation
+	}{
+		{"bar", &Deprecation{Name: "bar", URL: "https://plugins.jenkins.io/bar/#deprecation"}},
+		{"qux", &Deprecation{Name: "qux", URL: "https://plugins.jenkins.io/qux/#deprecation", Replacement: "foo"}},
+		{"foo", nil},
+	}
+	for _, tc := range testCases {
+		if got := c.Deprecation(tc.name); !reflect.DeepEqual(got, tc.deprecation) {
+			t.Errorf("%s: wanted: %+v, got: %+v", tc.name, tc.deprecation, got)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package catalog
+
+// Deprecation is a deprecated plugin published by the update center
+type Deprecation struct {
+	Name string `json:"-"`
+	// URL explains the deprecation (e.g. the plugin documentation)
+	URL string `json:"url"`
+	// Replacement is the plugin that replaces the deprecated one. It is not
+	// published by the Jenkins update center, but mirrors may add it.
+	Replacement string `json:"replacement"`
+}
+
+// addDeprecation adds a deprecation to the catalog. The first file providing a
+// deprecation wins.
+func (c *Catalog) addDeprecation(d *Deprecation) {
+	if c.deprecations == nil {
+		c.deprecations = map[string]*Deprecation{}
+	}
+	if _, ok := c.deprecations[d.Name]; !ok {
+		c.deprecations[d.Name] = d
+	}
+}
+
+// Deprecation returns the deprecation of a plugin (nil if it is not deprecated)
+func (c *Catalog) Deprecation(name string) *Deprecation {
+	return c.deprecations[name]
+}
@@ -0,0 +1,37 @@
+package catalog
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/httpdownloader"
+	"github.com/google/renameio"
+	"github.com/juju/errors"
+)
+
+const (
+	timeoutMin = 5
+)
+
+// Fetch downloads a catalog from an update center mirror to the store and it
+// returns the path to the catalog. It is always downloaded again, as the update
+// center data (e.g. the security warnings) changes over time.
+func Fetch(url string, workingDir string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutMin*time.Minute)
+	defer cancel()
+
+	catalogPath := GetCatalogPath(url, workingDir)
+	log.Printf("> downloading %s...\n", url)
+
+	t, err := renameio.TempFile("", catalogPath)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer t.Cleanup()
+
+	if err := httpdownloader.Download(ctx, url, t); err != nil {
+		return "", errors.Annotatef(err, "unable to download %q", url)
+	}
+	return catalogPath, t.CloseAtomicallyReplace()
+}
@@ -0,0 +1,17 @@
+package catalog
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+)
+
+// GetStorePath returns the path to the store of downloaded catalogs
+func GetStorePath(workingDir string) string {
+	return filepath.Join(workingDir, "catalog")
+}
+
+// GetCatalogPath returns the path to a downloaded catalog in the store
+func GetCatalogPath(url string, workingDir string) string {
+	return filepath.Join(GetStorePath(workingDir), fmt.Sprintf("%x.json", sha256.Sum256([]byte(url))))
+}
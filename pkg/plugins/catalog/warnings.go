@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/juju/errors"
+)
+
+// unknownSeverity is the severity of warnings that do not publish it
+const unknownSeverity = "unknown"
+
+// Warning is a security warning published by the update center
+type Warning struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	URL     string `json:"url"`
+	// Severity is not published by the Jenkins update center, but mirrors may
+	// add it. It defaults to "unknown".
+	Severity string            `json:"severity"`
+	Versions []*WarningVersion `json:"versions"`
+}
+
+// WarningVersion is a range of versions affected by a warning
+type WarningVersion struct {
+	LastVersion string `json:"lastVersion"`
+	// Pattern is a regular expression that matches the whole affected versions
+	Pattern string `json:"pattern"`
+
+	re *regexp.Regexp
+}
+
+func (w *Warning) compile() error {
+	if w.Severity == "" {
+		w.Severity = unknownSeverity
+	}
+	for _, v := range w.Versions {
+		re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", v.Pattern))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		v.re = re
+	}
+	return nil
+}
+
+// Affects returns whether a version of the plugin is affected by the warning
+func (w *Warning) Affects(version string) bool {
+	for _, v := range w.Versions {
+		if v.re != nil && v.re.MatchString(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// addWarning adds a warning to the catalog. The first file providing a warning wins.
+func (c *Catalog) addWarning(w *Warning) {
+	if c.warnings == nil {
+		c.warnings = map[string][]*Warning{}
+	}
+	for _, cw := range c.warnings[w.Name] {
+		if cw.ID == w.ID {
+			return
+		}
+	}
+	c.warnings[w.Name] = append(c.warnings[w.Name], w)
+}
+
+// Warnings returns the security warnings affecting a version of a plugin
+func (c *Catalog) Warnings(name string, version string) []*Warning {
+	warnings := []*Warning{}
+	for _, w := range c.warnings[name] {
+		if w.Affects(version) {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
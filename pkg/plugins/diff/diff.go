@@ -0,0 +1,186 @@
+// Package diff compares lock files and classifies the plugin changes
+// between them, so they are easy to review.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/juju/errors"
+)
+
+// Kinds of changes
+const (
+	Added      = "added"
+	Removed    = "removed"
+	Upgraded   = "upgraded"
+	Downgraded = "downgraded"
+)
+
+// Change represents a plugin (or the Jenkins core) change between two lock files
+type Change struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// From is the old version (empty for added plugins)
+	From string `json:"from,omitempty"`
+	// To is the new version (empty for removed plugins)
+	To string `json:"to,omitempty"`
+	// Major means that the major version changed (upgrades and downgrades only)
+	Major bool `json:"major,omitempty"`
+}
+
+// Diff represents the changes between two lock files
+type Diff struct {
+	// Jenkins is the Jenkins core change (if any)
+	Jenkins *Change  `json:"jenkins,omitempty"`
+	Plugins []Change `json:"plugins"`
+}
+
+// Compare returns the changes from the old lock file to the new one
+func Compare(old *api.PluginsRegistry, new *api.PluginsRegistry) (*Diff, error) {
+	oldPlugins := map[string]string{}
+	for _, p := range old.GetPlugins() {
+		oldPlugins[p.Name] = p.Version
+	}
+	newPlugins := map[string]string{}
+	for _, p := range new.GetPlugins() {
+		newPlugins[p.Name] = p.Version
+	}
+
+	names := []string{}
+	for name := range oldPlugins {
+		names = append(names, name)
+	}
+	for name := range newPlugins {
+		if _, ok := oldPlugins[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	d := &Diff{Plugins: []Change{}}
+	for _, name := range names {
+		c, err := compareVersions(name, oldPlugins[name], newPlugins[name])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if c != nil {
+			d.Plugins = append(d.Plugins, *c)
+		}
+	}
+
+	c, err := compareVersions("jenkins", old.GetJenkins().GetVersion(), new.GetJenkins().GetVersion())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	d.Jenkins = c
+
+	return d, nil
+}
+
+// compareVersions returns the change between two versions. It returns nil
+// if there is no change. Empty versions mean missing plugins.
+func compareVersions(name string, from string, to string) (*Change, error) {
+	c := &Change{Name: name, From: from, To: to}
+	switch {
+	case from == to:
+		return nil, nil
+	case from == "":
+		c.Kind = Added
+	case to == "":
+		c.Kind = Removed
+	default:
+		lower, err := utils.VersionLower(from, to)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		c.Kind = Downgraded
+		if lower {
+			c.Kind = Upgraded
+		}
+		c.Major = utils.MajorVersion(from) != utils.MajorVersion(to)
+	}
+	return c, nil
+}
+
+// Empty returns whether there are no changes
+func (d *Diff) Empty() bool {
+	return d.Jenkins == nil && len(d.Plugins) == 0
+}
+
+// Summary returns a one-line summary of the plugin changes
+func (d *Diff) Summary() string {
+	counts := map[string]int{}
+	majors := map[string]int{}
+	for _, c := range d.Plugins {
+		counts[c.Kind]++
+		if c.Major {
+			majors[c.Kind]++
+		}
+	}
+	var parts []string
+	for _, kind := range []string{Added, Removed, Upgraded, Downgraded} {
+		part := fmt.Sprintf("%d %s", counts[kind], kind)
+		if majors[kind] > 0 {
+			part += fmt.Sprintf(" (%d major)", majors[kind])
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteJSON writes the changes in JSON format
+func (d *Diff) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return errors.Trace(e.Encode(d))
+}
+
+// WriteMarkdown writes the changes as a Markdown table. Major version
+// changes are highlighted.
+func (d *Diff) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	if d.Empty() {
+		b.WriteString("No changes in the lock file.\n")
+		_, err := io.WriteString(w, b.String())
+		return errors.Trace(err)
+	}
+
+	if d.Jenkins != nil {
+		fmt.Fprintf(&b, "**Jenkins core**: %s\n\n", describe(d.Jenkins))
+	}
+	fmt.Fprintf(&b, "**Plugins**: %s\n", d.Summary())
+	if len(d.Plugins) > 0 {
+		b.WriteString("\n| **Plugin** | **Change** | **Old** | **New** |\n")
+		b.WriteString("| ---------- | ---------- | ------- | ------- |\n")
+		for _, c := range d.Plugins {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", c.Name, kind(&c), c.From, c.To)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.Trace(err)
+}
+
+// kind returns the kind of change. Major changes are highlighted
+// (e.g. "major upgrade").
+func kind(c *Change) string {
+	if c.Major {
+		return fmt.Sprintf(":warning: **major %s**", strings.TrimSuffix(c.Kind, "d"))
+	}
+	return c.Kind
+}
+
+func describe(c *Change) string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("%s (added)", c.To)
+	case Removed:
+		return fmt.Sprintf("%s (removed)", c.From)
+	}
+	return fmt.Sprintf("%s → %s (%s)", c.From, c.To, kind(c))
+}
@@ -0,0 +1,142 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/golang/protobuf/jsonpb"
+)
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		old      string
+		new      string
+		markdown string
+	}{
+		// No changes scenario; metadata changes are ignored
+		{
+			`{
+				"plugins": [{
+					"name": "foo",
+					"version": "1.0"
+				}]
+			}`, `{
+				"plugins": [{
+					"name": "foo",
+					"version": "1.0",
+					"requester": "project file"
+				}]
+			}`,
+			"No changes in the lock file.\n",
+		},
+		// Regular scenario; all kind of changes get classified
+		{
+			`{
+				"plugins": [{
+					"name": "bar",
+					"version": "1.0"
+				}, {
+					"name": "baz",
+					"version": "2.0"
+				}, {
+					"name": "foo",
+					"version": "1.0"
+				}, {
+					"name": "qux",
+					"version": "1.0"
+				}]
+			}`, `{
+				"plugins": [{
+					"name": "bar",
+					"version": "1.1"
+				}, {
+					"name": "baz",
+					"version": "1.9"
+				}, {
+					"name": "foo",
+					"version": "2.0"
+				}, {
+					"name": "quux",
+					"version": "1.0"
+				}]
+			}`,
+			"**Plugins**: 1 added, 1 removed, 2 upgraded (1 major), 1 downgraded (1 major)\n" +
+				"\n" +
+				"| **Plugin** | **Change** | **Old** | **New** |\n" +
+				"| ---------- | ---------- | ------- | ------- |\n" +
+				"| `bar` | upgraded | 1.0 | 1.1 |\n" +
+				"| `baz` | :warning: **major downgrade** | 2.0 | 1.9 |\n" +
+				"| `foo` | :warning: **major upgrade** | 1.0 | 2.0 |\n" +
+				"| `quux` | added |  | 1.0 |\n" +
+				"| `qux` | removed | 1.0 |  |\n",
+		},
+		// Jenkins core scenario; the core change is reported too
+		{
+			`{
+				"jenkins": {
+					"version": "2.176.3"
+				}
+			}`, `{
+				"jenkins": {
+					"version": "2.190.1"
+				}
+			}`,
+			"**Jenkins core**: 2.176.3 → 2.190.1 (upgraded)\n" +
+				"\n" +
+				"**Plugins**: 0 added, 0 removed, 0 upgraded, 0 downgraded\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		old := &api.PluginsRegistry{}
+		if err := jsonpb.UnmarshalString(tc.old, old); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		new := &api.PluginsRegistry{}
+		if err := jsonpb.UnmarshalString(tc.new, new); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		d, err := Compare(old, new)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		var b bytes.Buffer
+		if err := d.WriteMarkdown(&b); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if got := b.String(); got != tc.markdown {
+			t.Errorf("wanted: %q, got: %q", tc.markdown, got)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	d, err := Compare(
+		&api.PluginsRegistry{Plugins: []*api.Plugin{{Name: "foo", Version: "1.0"}}},
+		&api.PluginsRegistry{Plugins: []*api.Plugin{{Name: "foo", Version: "2.0"}}},
+	)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var b bytes.Buffer
+	if err := d.WriteJSON(&b); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	want := `{
+  "plugins": [
+    {
+      "name": "foo",
+      "kind": "upgraded",
+      "from": "1.0",
+      "to": "2.0",
+      "major": true
+    }
+  ]
+}
+`
+	if got := b.String(); got != want {
+		t.Errorf("wanted: %q, got: %q", want, got)
+	}
+}
@@ -12,3 +12,11 @@ type Downloader interface {
 	Download(context.Context, *api.Plugin, io.Writer) error
 	GetDownloadURL(*api.Plugin) string
 }
+
+// WarDownloader wraps a common interface for Jenkins war downloader implementations
+type WarDownloader interface {
+	DownloadWar(context.Context, string, io.Writer) error
+	GetWarDownloadURL(string) string
+	// GetWarChecksum returns the published SHA-256 checksum for the given version
+	GetWarChecksum(context.Context, string) (string, error)
+}
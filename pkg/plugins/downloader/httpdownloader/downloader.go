@@ -1,9 +1,11 @@
 package httpdownloader
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/juju/errors"
 )
@@ -28,3 +30,17 @@ func Download(ctx context.Context, url string, w io.Writer) error {
 	_, err = io.Copy(w, resp.Body)
 	return err
 }
+
+// DownloadChecksum fetches a checksum file from a HTTP/HTTPS endpoint and it
+// returns the checksum. The file follows the sha256sum format: "<checksum>  <file>"
+func DownloadChecksum(ctx context.Context, url string) (string, error) {
+	var b bytes.Buffer
+	if err := Download(ctx, url, &b); err != nil {
+		return "", errors.Annotatef(err, "unable to download %q", url)
+	}
+	fields := strings.Fields(b.String())
+	if len(fields) == 0 {
+		return "", errors.Errorf("malformed checksum file %q", url)
+	}
+	return strings.ToLower(fields[0]), nil
+}
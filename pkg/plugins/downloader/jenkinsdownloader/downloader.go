@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/httpdownloader"
@@ -12,16 +13,20 @@ import (
 // Downloader wrapps the official Jenkins downloads URL around a PluginsFetcher
 type Downloader struct {
 	URL string
+	// WarURL is the Jenkins wars mirror
+	WarURL string
 }
 
 const (
-	jenkinsURL = "https://updates.jenkins.io/download"
+	jenkinsURL    = "https://updates.jenkins.io/download"
+	jenkinsWarURL = "https://get.jenkins.io"
 )
 
 // NewDownloader will return a new fetcher
 func NewDownloader() *Downloader {
 	return &Downloader{
-		URL: jenkinsURL,
+		URL:    jenkinsURL,
+		WarURL: jenkinsWarURL,
 	}
 }
 
@@ -36,3 +41,26 @@ func (d *Downloader) Download(ctx context.Context, p *api.Plugin, w io.Writer) e
 	url := d.GetDownloadURL(p)
 	return httpdownloader.Download(ctx, url, w)
 }
+
+// GetWarDownloadURL prints the URL for the given Jenkins version. LTS versions
+// (x.y.z) are published in a different folder than the weekly ones (x.y).
+func (d *Downloader) GetWarDownloadURL(version string) string {
+	folder := "war"
+	if strings.Count(version, ".") > 1 {
+		folder = "war-stable"
+	}
+	return fmt.Sprintf("%s/%s/%s/jenkins.war", d.WarURL, folder, version)
+}
+
+// DownloadWar will fetch a Jenkins war from the mirror and will write it
+// to the provided writer.
+func (d *Downloader) DownloadWar(ctx context.Context, version string, w io.Writer) error {
+	url := d.GetWarDownloadURL(version)
+	return httpdownloader.Download(ctx, url, w)
+}
+
+// GetWarChecksum returns the SHA-256 checksum published next to the war.
+// The checksum file follows the sha256sum format: "<checksum>  jenkins.war"
+func (d *Downloader) GetWarChecksum(ctx context.Context, version string) (string, error) {
+	return httpdownloader.DownloadChecksum(ctx, d.GetWarDownloadURL(version)+".sha256")
+}
@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	api "github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/catalog"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/requesters"
+)
+
+// Deprecation represents a deprecated locked plugin
+type Deprecation struct {
+	Plugin      *api.Plugin
+	Deprecation *catalog.Deprecation
+	// Hint explains how to migrate the project file away from the plugin
+	Hint       string
+	Requesters []string
+}
+
+// Deprecations is a list of deprecated plugins
+type Deprecations []Deprecation
+
+// Print prints a list of deprecated plugins
+func (ds Deprecations) Print() {
+	for nd, d := range ds {
+		dSep := "├"
+		sep := "│"
+		if nd == len(ds)-1 {
+			dSep = "└"
+			sep = " "
+		}
+		log.Printf("  %s── Plugin: %s (%s)\n", dSep, d.Plugin.Identifier(), d.Plugin.Requester)
+		log.Printf("  %s   Deprecation: %s\n", sep, d.Deprecation.URL)
+		if d.Deprecation.Replacement != "" {
+			log.Printf("  %s   Replacement: %s\n", sep, d.Deprecation.Replacement)
+		}
+		log.Printf("  %s   Hint: %s\n", sep, d.Hint)
+
+		for nr, req := range d.Requesters {
+			if nr == len(d.Requesters)-1 {
+				log.Printf("  %s     └── %s\n", sep, req)
+			} else {
+				log.Printf("  %s     ├── %s\n", sep, req)
+			}
+		}
+		log.Printf("  %s\n", sep)
+	}
+}
+
+// FindDeprecations walks through a graph and reports the locked plugins that are
+// deprecated in a catalog, with the chains of plugins requiring them and a hint
+// to migrate the list of requested plugins away from them.
+func FindDeprecations(plugins []*api.Plugin, lockedPlugins []*api.Plugin, g *api.Graph, c *catalog.Catalog) Deprecations {
+	requested := map[string]*api.Plugin{}
+	for _, p := range plugins {
+		requested[p.Name] = p
+	}
+	locked := append([]*api.Plugin{}, lockedPlugins...)
+	sort.Sort(api.ByName(locked))
+
+	deprecations := Deprecations{}
+	for _, lp := range locked {
+		d := c.Deprecation(lp.Name)
+		if d == nil {
+			continue
+		}
+		p := &api.Plugin{Name: lp.Name, Version: lp.Version, Requester: requesters.TRANSITIVE}
+		if rp, ok := requested[lp.Name]; ok {
+			p.Requester = rp.Requester
+		}
+
+		reqs := []string{}
+		if p.Requester == requesters.TRANSITIVE {
+			for _, n := range g.Nodes {
+				reqs = findPluginRequesters(n, lp, reqs, []string{})
+			}
+		}
+		deprecations = append(deprecations, Deprecation{
+			Plugin:      p,
+			Deprecation: d,
+			Hint:        migrationHint(p, d),
+			Requesters:  reqs,
+		})
+	}
+	return deprecations
+}
+
+// migrationHint returns how to migrate the project file away from a deprecated plugin
+func migrationHint(p *api.Plugin, d *catalog.Deprecation) string {
+	switch p.Requester {
+	case requesters.PROJECT, requesters.OVERRIDE:
+		if d.Replacement != "" {
+			return fmt.Sprintf("replace %s with %s in the project file", p.Name, d.Replacement)
+		}
+		return fmt.Sprintf("remove %s from the project file once it is not needed", p.Name)
+	case requesters.WAR:
+		return fmt.Sprintf("%s is bundled with the Jenkins core. Add it to the project file excludes if no plugin depends on it", p.Name)
+	}
+	if d.Replacement != "" {
+		return fmt.Sprintf("upgrade or replace the plugins requiring %s, and add %s to the project file if it is needed", p.Name, d.Replacement)
+	}
+	return fmt.Sprintf("upgrade or replace the plugins requiring %s", p.Name)
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	api "github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/catalog"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/requesters"
+	"github.com/golang/protobuf/jsonpb"
+)
+
+func TestFindDeprecations(t *testing.T) {
+	c, err := catalog.Read("testdata/update-center.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	g := &api.Graph{}
+	if err := jsonpb.UnmarshalString(`{
+		"nodes": [{
+			"plugin": {"name": "foo", "version": "1.0", "requester": "project file"},
+			"dependencies": [{"plugin": {"name": "bar", "version": "1.2"}}]
+		}, {
+			"plugin": {"name": "baz", "version": "2.0", "requester": "project file"}
+		}]
+	}`, g); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	plugins := []*api.Plugin{
+		{Name: "foo", Version: "1.0", Requester: requesters.PROJECT},
+		{Name: "baz", Version: "2.0", Requester: requesters.PROJECT},
+	}
+	locked := []*api.Plugin{
+		{Name: "foo", Version: "1.0"},
+		{Name: "baz", Version: "2.0"},
+		{Name: "bar", Version: "1.2"},
+	}
+
+	ds := FindDeprecations(plugins, locked, g, c)
+	want := []struct {
+		plugin     string
+		requester  string
+		hint       string
+		requesters []string
+	}{
+		{"bar:1.2", requesters.TRANSITIVE, "upgrade or replace the plugins requiring bar", []string{"foo:1.0 (project file) > bar:1.2"}},
+		{"baz:2.0", requesters.PROJECT, "replace baz with qux in the project file", []string{}},
+	}
+	if len(ds) != len(want) {
+		t.Fatalf("wanted %d deprecations, got: %+v", len(want), ds)
+	}
+	for i, w := range want {
+		d := ds[i]
+		if d.Plugin.Identifier() != w.plugin || d.Plugin.Requester != w.requester || d.Hint != w.hint || !reflect.DeepEqual(d.Requesters, w.requesters) {
+			t.Errorf("wanted: %+v, got: %s (%s) %q %v", w, d.Plugin.Identifier(), d.Plugin.Requester, d.Hint, d.Requesters)
+		}
+	}
+}
@@ -11,12 +11,17 @@ import (
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/common"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/meta"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/golang/protobuf/proto"
 	"github.com/juju/errors"
 	"github.com/mkmik/multierror"
 )
 
 const (
 	timeoutMin = 5
+	// graphSchema is the version of the graph format. It must be bumped
+	// whenever the way graphs are computed changes, so stored graphs get
+	// invalidated.
+	graphSchema = 1
 )
 
 // WriteGraph will write a graph into a file
@@ -66,28 +71,80 @@ func NewNode(p *api.Plugin, workingDir string, optional bool) (*api.Graph_Node,
 	return &node, nil
 }
 
-// FetchGraph computes the graph for a list of plugins or read it from the store
-func FetchGraph(plugins []*api.Plugin, d common.Downloader, workingDir string, maxWorkers int, optional bool) (*api.Graph, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeoutMin*time.Minute)
-	defer cancel()
-
+// NewGraphKey returns the key that identifies the graph for the given list of plugins,
+// resolution options and Jenkins core version (empty if there is no core).
+func NewGraphKey(plugins []*api.Plugin, optional bool, jenkinsVersion string) *api.GraphKey {
 	// NOTE: We need to ensure that the list of plugins are properly
 	//       sorted before computing its hash.
 	sort.Sort(api.ByName(plugins))
-	hash, err := crypto.SHA256(&api.PluginsRegistry{Plugins: plugins})
+	return &api.GraphKey{
+		Schema:         graphSchema,
+		Plugins:        plugins,
+		Optional:       optional,
+		JenkinsVersion: jenkinsVersion,
+	}
+}
+
+// SubGraph returns the graph for a subset of the plugins a graph was computed for.
+// It allows several lists of plugins to share a single graph fetch.
+func SubGraph(g *api.Graph, plugins []*api.Plugin) *api.Graph {
+	nodes := []*api.Graph_Node{}
+	for _, n := range g.Nodes {
+		for _, p := range plugins {
+			if proto.Equal(n.Plugin, p) {
+				nodes = append(nodes, n)
+				break
+			}
+		}
+	}
+	return &api.Graph{
+		Nodes: nodes,
+		Key:   NewGraphKey(plugins, g.GetKey().GetOptional(), g.GetKey().GetJenkinsVersion()),
+	}
+}
+
+// readCachedGraph reads a graph from the store. It returns nil if the graph
+// does not exist or it was not computed for the given key (stale graph).
+func readCachedGraph(graphPath string, key *api.GraphKey) (*api.Graph, error) {
+	cached, err := utils.FileExists(graphPath)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if !cached {
+		return nil, nil
+	}
 
-	graphPath := GetGraphPath(hash, workingDir)
-	cached, err := utils.FileExists(graphPath)
+	log.Printf("Reading graph from disk: %s\n", graphPath)
+	g, err := ReadGraph(graphPath)
+	if err != nil {
+		log.Printf("Ignoring unreadable graph %s: %v\n", graphPath, err)
+		return nil, nil
+	}
+	if !proto.Equal(g.Key, key) {
+		log.Printf("Ignoring stale graph %s\n", graphPath)
+		return nil, nil
+	}
+	return g, nil
+}
+
+// FetchGraph computes the graph for a list of plugins or read it from the store.
+// Stored graphs are only reused if they were computed with the same options
+// for the same Jenkins core version. See NewGraphKey.
+func FetchGraph(plugins []*api.Plugin, d common.Downloader, workingDir string, maxWorkers int, optional bool, jenkinsVersion string) (*api.Graph, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutMin*time.Minute)
+	defer cancel()
+
+	key := NewGraphKey(plugins, optional, jenkinsVersion)
+	hash, err := crypto.SHA256(key)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 
-	if cached {
-		log.Printf("Reading graph from disk: %s\n", graphPath)
-		return ReadGraph(graphPath)
+	graphPath := GetGraphPath(hash, workingDir)
+	if g, err := readCachedGraph(graphPath, key); err != nil {
+		return nil, errors.Trace(err)
+	} else if g != nil {
+		return g, nil
 	}
 
 	log.Println("Computing graph...")
@@ -112,6 +169,7 @@ func FetchGraph(plugins []*api.Plugin, d common.Downloader, workingDir string, m
 
 	g := api.Graph{
 		Nodes: nodes,
+		Key:   key,
 	}
 	if err := WriteGraph(&g, graphPath); err != nil {
 		return nil, errors.Trace(err)
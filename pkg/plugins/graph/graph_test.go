@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/crypto"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/common"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestNewGraphKey(t *testing.T) {
+	plugins := func() []*api.Plugin {
+		return []*api.Plugin{{Name: "foo", Version: "1.0"}, {Name: "bar", Version: "1.0"}}
+	}
+	keys := []*api.GraphKey{
+		NewGraphKey(plugins(), false, ""),
+		NewGraphKey(plugins(), true, ""),
+		NewGraphKey(plugins(), false, "2.176.3"),
+		NewGraphKey(plugins()[:1], false, ""),
+	}
+	hashes := map[string]bool{}
+	for _, key := range keys {
+		hash, err := crypto.SHA256(key)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if hashes[hash] {
+			t.Errorf("the %s key collides with another key", key)
+		}
+		hashes[hash] = true
+	}
+
+	// The order of the plugins does not matter
+	reversed := plugins()
+	reversed[0], reversed[1] = reversed[1], reversed[0]
+	if got := NewGraphKey(reversed, false, ""); !proto.Equal(got, keys[0]) {
+		t.Errorf("wanted: %s, got: %s", keys[0], got)
+	}
+}
+
+func TestSubGraph(t *testing.T) {
+	foo1 := &api.Plugin{Name: "foo", Version: "1.0", Requester: "project file"}
+	foo2 := &api.Plugin{Name: "foo", Version: "2.0", Requester: "project file"}
+	bar := &api.Plugin{Name: "bar", Version: "1.0", Requester: "war"}
+	dep := &api.Graph_Node{Plugin: &api.Plugin{Name: "baz", Version: "1.0"}}
+	g := &api.Graph{
+		Nodes: []*api.Graph_Node{
+			{Plugin: bar},
+			{Plugin: foo1, Dependencies: []*api.Graph_Node{dep}},
+			{Plugin: foo2, Dependencies: []*api.Graph_Node{dep}},
+		},
+		Key: NewGraphKey([]*api.Plugin{foo1, foo2, bar}, true, "2.176.3"),
+	}
+
+	got := SubGraph(g, []*api.Plugin{
+		{Name: "foo", Version: "2.0", Requester: "project file"},
+		{Name: "bar", Version: "1.0", Requester: "war"},
+	})
+	want := &api.Graph{
+		Nodes: []*api.Graph_Node{g.Nodes[0], g.Nodes[2]},
+		Key:   NewGraphKey([]*api.Plugin{foo2, bar}, true, "2.176.3"),
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("wanted: %s, got: %s", want, got)
+	}
+}
+
+func TestFetchGraphStale(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "jpr")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(workingDir)
+	if err := common.EnsureStorePathExists(workingDir, GetStorePath); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	key := NewGraphKey(nil, true, "2.176.3")
+	hash, err := crypto.SHA256(key)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		name  string
+		graph *api.Graph
+	}{
+		{"missing key", &api.Graph{
+			Nodes: []*api.Graph_Node{{Plugin: &api.Plugin{Name: "foo", Version: "1.0"}}},
+		}},
+		{"different key", &api.Graph{
+			Nodes: []*api.Graph_Node{{Plugin: &api.Plugin{Name: "foo", Version: "1.0"}}},
+			Key:   &api.GraphKey{Optional: true, JenkinsVersion: "2.176.3"},
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := WriteGraph(tc.graph, GetGraphPath(hash, workingDir)); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			g, err := FetchGraph(nil, nil, workingDir, 1, true, "2.176.3")
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if want := (&api.Graph{Key: key}); !proto.Equal(g, want) {
+				t.Errorf("wanted: %s, got: %s", want, g)
+			}
+
+			// The recomputed graph replaces the stale one
+			stored, err := ReadGraph(GetGraphPath(hash, workingDir))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if !proto.Equal(stored, g) {
+				t.Errorf("wanted: %s, got: %s", g, stored)
+			}
+		})
+	}
+}
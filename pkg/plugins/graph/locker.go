@@ -1,15 +1,38 @@
 package graph
 
 import (
+	"fmt"
+	"log"
 	"sort"
+	"strings"
 
 	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/requesters"
 	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
 	"github.com/juju/errors"
 	"github.com/mkmik/multierror"
 )
 
-type pluginsMap map[string]string
+// lockEntry represents a locked plugin and the reasons it was locked for
+type lockEntry struct {
+	version   string
+	requester string
+	// requiredBy is the set of plugins that depend on this one
+	requiredBy map[string]bool
+	// required means that there is, at least, one non-optional path to this plugin
+	required bool
+}
+
+type pluginsMap map[string]*lockEntry
+
+// versions returns the locked version of every plugin
+func (pm pluginsMap) versions() map[string]string {
+	versions := make(map[string]string, len(pm))
+	for name, e := range pm {
+		versions[name] = e.version
+	}
+	return versions
+}
 
 // newPluginsRegistry iterates a map of plugins and creates a new plugins registry
 func (pm pluginsMap) newPluginsRegistry() *api.PluginsRegistry {
@@ -21,10 +44,17 @@ func (pm pluginsMap) newPluginsRegistry() *api.PluginsRegistry {
 
 	plugins := []*api.Plugin{}
 	for _, name := range names {
+		e := pm[name]
 		p := api.Plugin{
-			Name:    name,
-			Version: pm[name],
+			Name:      name,
+			Version:   e.version,
+			Requester: e.requester,
+			Optional:  !e.required,
+		}
+		for parent := range e.requiredBy {
+			p.RequiredBy = append(p.RequiredBy, parent)
 		}
+		sort.Strings(p.RequiredBy)
 		plugins = append(plugins, &p)
 	}
 	return &api.PluginsRegistry{
@@ -32,13 +62,165 @@ func (pm pluginsMap) newPluginsRegistry() *api.PluginsRegistry {
 	}
 }
 
-func updatePluginsMap(pm pluginsMap, p *api.Plugin) error {
-	if ok, err := utils.VersionLower(pm[p.Name], p.Version); err != nil {
+// Options configures how the plugins are locked
+type Options struct {
+	// Optional adds the optional dependencies to the lock
+	Optional bool
+	// Overrides forces the version of transitive plugins, regardless of the
+	// versions required by other plugins
+	Overrides map[string]string
+	// Excludes lists plugins that are never locked. Their dependencies are
+	// not locked either, unless other plugins depend on them.
+	Excludes []string
+	// Strict forbids bumping the version of requested plugins. It fails if
+	// other plugins require a newer version instead.
+	Strict bool
+	// Strategy selects the version of every plugin (MaxVersion by default)
+	Strategy Strategy
+}
+
+func (o *Options) excluded(name string) bool {
+	for _, e := range o.Excludes {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// locker resolves the plugins of a graph into a plugins map
+type locker struct {
+	opts    *Options
+	plugins pluginsMap
+	// warnings is the set of issues found while locking the plugins
+	warnings map[string]bool
+	// pins are the versions of the requested plugins (strict mode)
+	pins map[string]string
+	// violations is the set of requirements that would bump a pinned plugin
+	violations map[string]bool
+	// path is the chain of plugins from a graph node to the current one
+	path []*api.Plugin
+	// selected are the versions chosen by a previous pass (minimal version
+	// selection). The dependencies of other versions are not locked.
+	selected map[string]string
+}
+
+func newLocker(opts *Options) *locker {
+	return &locker{
+		opts:       opts,
+		plugins:    pluginsMap{},
+		warnings:   map[string]bool{},
+		pins:       map[string]string{},
+		violations: map[string]bool{},
+	}
+}
+
+func (lk *locker) push(p *api.Plugin) {
+	lk.path = append(lk.path, p)
+}
+
+func (lk *locker) pop() {
+	lk.path = lk.path[:len(lk.path)-1]
+}
+
+// parentsChain returns the path of plugins to the current one (excluded),
+// i.e. "foo:1.0 (project file) > bar:2.0"
+func (lk *locker) parentsChain() string {
+	parents := lk.path[:len(lk.path)-1]
+	ids := make([]string, 0, len(parents))
+	for i, p := range parents {
+		if i == 0 {
+			ids = append(ids, fmt.Sprintf("%s (%s)", p.Identifier(), p.Requester))
+			continue
+		}
+		ids = append(ids, p.Identifier())
+	}
+	return strings.Join(ids, " > ")
+}
+
+func (lk *locker) warn(format string, args ...interface{}) {
+	lk.warnings[fmt.Sprintf(format, args...)] = true
+}
+
+// sortedWarnings returns the warnings found while locking the plugins
+func (lk *locker) sortedWarnings() []string {
+	warnings := make([]string, 0, len(lk.warnings))
+	for w := range lk.warnings {
+		warnings = append(warnings, w)
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// skip returns whether a node must not be locked (excluded plugins). It warns
+// when the excluded plugin is a required dependency of another plugin.
+func (lk *locker) skip(n *api.Graph_Node, parent *api.Plugin, viaOptional bool) bool {
+	if !lk.opts.excluded(n.Plugin.Name) {
+		return false
+	}
+	if parent != nil && !viaOptional {
+		lk.warn("the excluded %s plugin is required by %s", n.Plugin.Name, parent.Identifier())
+	}
+	return true
+}
+
+// traverse returns whether the dependencies of a node must be locked. The
+// dependencies of overridden plugins are only locked for the overridden
+// version (see lockOverrides), and the same applies to the selected versions.
+func (lk *locker) traverse(n *api.Graph_Node) bool {
+	if version, ok := lk.opts.Overrides[n.Plugin.Name]; ok && version != n.Plugin.Version {
+		return false
+	}
+	version, ok := lk.selected[n.Plugin.Name]
+	return !ok || version == n.Plugin.Version
+}
+
+// updatePluginsMap adds a plugin to the map. The parent is the plugin that depends
+// on it (nil for the graph nodes) and viaOptional means that the plugin was reached
+// through an optional dependency.
+func (lk *locker) updatePluginsMap(p *api.Plugin, parent *api.Plugin, viaOptional bool) error {
+	e, ok := lk.plugins[p.Name]
+	if !ok {
+		e = &lockEntry{
+			requester:  requesters.TRANSITIVE,
+			requiredBy: map[string]bool{},
+		}
+		lk.plugins[p.Name] = e
+	}
+
+	if parent == nil {
+		e.requester = p.Requester
+	} else {
+		e.requiredBy[parent.Name] = true
+	}
+	if !viaOptional {
+		e.required = true
+	}
+
+	version := p.Version
+	if override, ok := lk.opts.Overrides[p.Name]; ok {
+		if lower, err := utils.VersionLower(override, p.Version); err != nil {
+			return errors.Trace(err)
+		} else if lower && parent != nil {
+			lk.warn("the %s:%s override is lower than the version required by %s (%s)", p.Name, override, parent.Identifier(), p.Identifier())
+		}
+		version = override
+	}
+
+	if ok, err := utils.VersionLower(e.version, version); err != nil {
 		return errors.Trace(err)
 	} else if !ok {
 		return nil
 	}
-	pm[p.Name] = p.Version
+	if pin, ok := lk.pins[p.Name]; ok && parent != nil {
+		if higher, err := utils.VersionLower(pin, version); err != nil {
+			return errors.Trace(err)
+		} else if higher {
+			lk.violations[fmt.Sprintf("%s:%s is requested in the project file, but %s requires %s", p.Name, pin, lk.parentsChain(), p.Identifier())] = true
+			return nil
+		}
+	}
+	e.version = version
 	return nil
 }
 
@@ -48,18 +230,26 @@ func updatePluginsMap(pm pluginsMap, p *api.Plugin) error {
 // It will iterate over the graph nodes by accessing dependencies recursively.
 // If we want to resolve optional dependencies too (-optional flag) we will also
 // iterate over the optional dependencies, as we can consider them regular ones.
-func resolveNodeDependencies(n *api.Graph_Node, pm pluginsMap, optional bool) error {
-	if err := updatePluginsMap(pm, n.Plugin); err != nil {
+func (lk *locker) resolveNodeDependencies(n *api.Graph_Node, parent *api.Plugin, viaOptional bool) error {
+	if lk.skip(n, parent, viaOptional) {
+		return nil
+	}
+	lk.push(n.Plugin)
+	defer lk.pop()
+	if err := lk.updatePluginsMap(n.Plugin, parent, viaOptional); err != nil {
 		return errors.Trace(err)
 	}
+	if !lk.traverse(n) {
+		return nil
+	}
 	for _, nd := range n.Dependencies {
-		if err := resolveNodeDependencies(nd, pm, optional); err != nil {
+		if err := lk.resolveNodeDependencies(nd, n.Plugin, viaOptional); err != nil {
 			return errors.Trace(err)
 		}
 	}
-	if optional {
+	if lk.opts.Optional {
 		for _, nd := range n.OptionalDependencies {
-			if err := resolveNodeDependencies(nd, pm, optional); err != nil {
+			if err := lk.resolveNodeDependencies(nd, n.Plugin, true); err != nil {
 				return errors.Trace(err)
 			}
 		}
@@ -82,47 +272,183 @@ func resolveNodeDependencies(n *api.Graph_Node, pm pluginsMap, optional bool) er
 //
 // It seems this is not a documented behavior:
 // https://wiki.jenkins.io/display/JENKINS/Dependencies+among+plugins
-func resolveNodeOptionalDependencies(n *api.Graph_Node, pm pluginsMap, optional bool) error {
+func (lk *locker) resolveNodeOptionalDependencies(n *api.Graph_Node, parent *api.Plugin) error {
 	// If we don't want optional dependencies to be included in the output,
 	// we will only process those optional dependencies that have been already
 	// added to the map (they are real dependencies for another plugin)
-	if !optional && pm[n.Plugin.Name] == "" {
+	if _, ok := lk.plugins[n.Plugin.Name]; !lk.opts.Optional && !ok {
 		return nil
 	}
-	if err := updatePluginsMap(pm, n.Plugin); err != nil {
+	if lk.skip(n, parent, true) {
+		return nil
+	}
+	lk.push(n.Plugin)
+	defer lk.pop()
+	if err := lk.updatePluginsMap(n.Plugin, parent, parent != nil); err != nil {
 		return errors.Trace(err)
 	}
+	if !lk.traverse(n) {
+		return nil
+	}
 	for _, nd := range n.OptionalDependencies {
-		if err := resolveNodeOptionalDependencies(nd, pm, optional); err != nil {
+		if err := lk.resolveNodeOptionalDependencies(nd, n.Plugin); err != nil {
 			return errors.Trace(err)
 		}
 	}
 	return nil
 }
 
+// lockOverrides resolves the dependencies of the overridden versions. Graphs
+// include a node (with the requesters.OVERRIDE requester) for every override,
+// but they are only taken into account if some plugin depends on them.
+func (lk *locker) lockOverrides(nodes []*api.Graph_Node) error {
+	done := map[string]bool{}
+	for {
+		found := false
+		for _, n := range nodes {
+			if _, ok := lk.plugins[n.Plugin.Name]; !ok || done[n.Plugin.Name] {
+				continue
+			}
+			done[n.Plugin.Name] = true
+			found = true
+			if err := lk.lockOverride(n); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+}
+
+// lockOverride resolves the dependencies of an override node
+func (lk *locker) lockOverride(n *api.Graph_Node) error {
+	lk.push(n.Plugin)
+	defer lk.pop()
+	for _, nd := range n.Dependencies {
+		if err := lk.resolveNodeDependencies(nd, n.Plugin, false); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if lk.opts.Optional {
+		for _, nd := range n.OptionalDependencies {
+			if err := lk.resolveNodeDependencies(nd, n.Plugin, true); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
 // LockPlugins generates a fully-qualified registry of plugins from a graph
 func LockPlugins(g *api.Graph, optional bool) (*api.PluginsRegistry, error) {
-	// Auxiliar mapping to set plugins by name
-	pm := make(pluginsMap)
+	return LockPluginsWithOptions(g, &Options{Optional: optional})
+}
 
-	// We need to resolve nodes dependencies first because they might include
-	// plugins that are optional dependencies for others.
+// LockPluginsWithOptions generates a fully-qualified registry of plugins from a graph
+// with the options strategy (MaxVersion by default). Overrides and exclusions that
+// break the requirements of other plugins are logged as warnings.
+func LockPluginsWithOptions(g *api.Graph, opts *Options) (*api.PluginsRegistry, error) {
+	s := opts.Strategy
+	if s == nil {
+		s = MaxVersion{}
+	}
+	return s.Lock(g, opts)
+}
+
+// lockGraph locks the plugins of a graph. The dependencies of the plugins whose
+// version is not the selected one are ignored (nil means no selection).
+func lockGraph(g *api.Graph, opts *Options, selected map[string]string) (*locker, error) {
+	lk := newLocker(opts)
+	lk.selected = selected
+	if err := lk.lock(g); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return lk, nil
+}
+
+// registry logs the warnings and returns the locked plugins
+func (lk *locker) registry() *api.PluginsRegistry {
+	for _, w := range lk.sortedWarnings() {
+		log.Printf("WARNING: %s\n", w)
+	}
+	return lk.plugins.newPluginsRegistry()
+}
+
+func (lk *locker) lock(g *api.Graph) error {
 	var errs error
+	var nodes, overrides []*api.Graph_Node
 	for _, n := range g.Nodes {
-		if err := resolveNodeDependencies(n, pm, optional); err != nil {
+		switch {
+		case n.Plugin.Requester == requesters.OVERRIDE:
+			// Ignore nodes of outdated overrides
+			if lk.opts.Overrides[n.Plugin.Name] == n.Plugin.Version {
+				overrides = append(overrides, n)
+			}
+		case n.Plugin.Requester == requesters.PROJECT && lk.opts.excluded(n.Plugin.Name):
+			errs = multierror.Append(errs, errors.Errorf("the %s plugin is requested in the project file and excluded", n.Plugin.Name))
+		case n.Plugin.Requester == requesters.PROJECT && lk.opts.Overrides[n.Plugin.Name] != "":
+			errs = multierror.Append(errs, errors.Errorf("the %s plugin is requested in the project file and overridden. Please change the requested version instead", n.Plugin.Name))
+		default:
+			nodes = append(nodes, n)
+			if lk.opts.Strict && n.Plugin.Requester == requesters.PROJECT {
+				lk.pins[n.Plugin.Name] = n.Plugin.Version
+			}
+		}
+	}
+	if errs != nil {
+		return errors.Trace(errs)
+	}
+
+	// We need to resolve nodes dependencies first because they might include
+	// plugins that are optional dependencies for others.
+	for _, n := range nodes {
+		if err := lk.resolveNodeDependencies(n, nil, false); err != nil {
 			errs = multierror.Append(errs, err)
 			continue
 		}
 	}
-	for _, n := range g.Nodes {
-		if err := resolveNodeOptionalDependencies(n, pm, optional); err != nil {
+	if errs != nil {
+		return errors.Trace(errs)
+	}
+	if err := lk.lockOverrides(overrides); err != nil {
+		return errors.Trace(err)
+	}
+	for _, n := range nodes {
+		if err := lk.resolveNodeOptionalDependencies(n, nil); err != nil {
 			errs = multierror.Append(errs, err)
 			continue
 		}
 	}
+	for _, n := range overrides {
+		if _, ok := lk.plugins[n.Plugin.Name]; !ok {
+			continue
+		}
+		lk.push(n.Plugin)
+		for _, nd := range n.OptionalDependencies {
+			if err := lk.resolveNodeOptionalDependencies(nd, n.Plugin); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+		lk.pop()
+	}
 	if errs != nil {
-		return nil, errors.Trace(errs)
+		return errors.Trace(errs)
 	}
+	return errors.Trace(lk.strictErrors())
+}
 
-	return pm.newPluginsRegistry(), nil
+// strictErrors returns the requirements that would bump a requested plugin (strict mode)
+func (lk *locker) strictErrors() error {
+	violations := make([]string, 0, len(lk.violations))
+	for v := range lk.violations {
+		violations = append(violations, v)
+	}
+	sort.Strings(violations)
+
+	var errs error
+	for _, v := range violations {
+		errs = multierror.Append(errs, errors.New(v))
+	}
+	return errs
 }
@@ -1,6 +1,8 @@
 package graph
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
@@ -53,7 +55,8 @@ func TestLockPlugins(t *testing.T) {
 			}`, `{
 				"plugins": [{
 					"name": "bar",
-					"version": "3.0"
+					"version": "3.0",
+					"required_by": ["foo"]
 				}, {
 					"name": "foo",
 					"version": "1.0"
@@ -102,7 +105,10 @@ func TestLockPlugins(t *testing.T) {
 			}`, `{
 				"plugins": [{
 					"name": "bar",
-					"version": "2.0"
+					"version": "2.0",
+					"requester": "transitive",
+					"required_by": ["foo"],
+					"optional": true
 				}, {
 					"name": "foo",
 					"version": "1.0"
@@ -142,10 +148,14 @@ func TestLockPlugins(t *testing.T) {
 			}`, `{
 				"plugins": [{
 					"name": "bar",
-					"version": "2.0"
+					"version": "2.0",
+					"requester": "transitive",
+					"required_by": ["faa", "foo"]
 				}, {
 					"name": "faa",
-					"version": "1.0"
+					"version": "1.0",
+					"requester": "transitive",
+					"required_by": ["foo"]
 				}, {
 					"name": "foo",
 					"version": "1.0"
@@ -184,7 +194,9 @@ func TestLockPlugins(t *testing.T) {
 			}`, `{
 				"plugins": [{
 					"name": "bar",
-					"version": "2.0"
+					"version": "2.0",
+					"requester": "transitive",
+					"required_by": ["faa", "foo"]
 				}, {
 					"name": "faa",
 					"version": "1.0"
@@ -194,6 +206,66 @@ func TestLockPlugins(t *testing.T) {
 				}]
 			}`, false,
 		},
+		// Provenance scenario; locked plugins record their requester
+		// and the plugins that depend on them
+		{
+			`{
+				"nodes": [{
+					"plugin": {
+						"name": "foo",
+						"version": "1.0",
+						"requester": "project file"
+					},
+					"dependencies": [{
+						"plugin": {
+							"name": "bar",
+							"version": "1.0"
+						}
+					}],
+					"optional_dependencies": [{
+						"plugin": {
+							"name": "baz",
+							"version": "1.0"
+						},
+						"dependencies": [{
+							"plugin": {
+								"name": "qux",
+								"version": "1.0"
+							}
+						}]
+					}]
+				}, {
+					"plugin": {
+						"name": "bar",
+						"version": "1.0",
+						"requester": "war"
+					}
+				}]
+			}`, `{
+				"plugins": [{
+					"name": "bar",
+					"version": "1.0",
+					"requester": "war",
+					"required_by": ["foo"]
+				}, {
+					"name": "baz",
+					"version": "1.0",
+					"requester": "transitive",
+					"required_by": ["foo"],
+					"optional": true
+				}, {
+					"name": "foo",
+					"version": "1.0",
+					"requester": "project file"
+				}, {
+					"name": "qux",
+					"version": "1.0",
+					"requester": "transitive",
+					"required_by": ["baz"],
+					"optional": true
+				}]
+			}`, true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -218,3 +290,175 @@ func TestLockPlugins(t *testing.T) {
 		}
 	}
 }
+
+func TestLockPluginsWithOptions(t *testing.T) {
+	// foo:1.0 requires bar:2.0 and it has qux:1.0 as optional dependency.
+	// The graph includes the bar:1.5 override node.
+	graphJSON := `{
+		"nodes": [{
+			"plugin": {"name": "foo", "version": "1.0", "requester": "project file"},
+			"dependencies": [{
+				"plugin": {"name": "bar", "version": "2.0"},
+				"dependencies": [{"plugin": {"name": "baz", "version": "1.0"}}]
+			}],
+			"optional_dependencies": [{
+				"plugin": {"name": "qux", "version": "1.0"},
+				"dependencies": [{"plugin": {"name": "quux", "version": "1.0"}}]
+			}]
+		}, {
+			"plugin": {"name": "bar", "version": "1.5", "requester": "override"},
+			"dependencies": [{"plugin": {"name": "corge", "version": "1.0"}}]
+		}, {
+			"plugin": {"name": "grault", "version": "1.0", "requester": "override"}
+		}]
+	}`
+	testCases := []struct {
+		name     string
+		opts     *Options
+		lock     string
+		warnings []string
+	}{
+		{
+			name: "overrides",
+			opts: &Options{Overrides: map[string]string{"bar": "1.5", "grault": "1.0"}},
+			// The dependencies of bar:2.0 are replaced by the bar:1.5 ones and
+			// grault is not locked as no plugin depends on it
+			lock: `{
+				"plugins": [
+					{"name": "bar", "version": "1.5", "requester": "transitive", "requiredBy": ["foo"]},
+					{"name": "corge", "version": "1.0", "requester": "transitive", "requiredBy": ["bar"]},
+					{"name": "foo", "version": "1.0", "requester": "project file"}
+				]
+			}`,
+			warnings: []string{"the bar:1.5 override is lower than the version required by foo:1.0 (bar:2.0)"},
+		},
+		{
+			name: "exclude optional dependency",
+			opts: &Options{Optional: true, Excludes: []string{"qux"}},
+			lock: `{
+				"plugins": [
+					{"name": "bar", "version": "2.0", "requester": "transitive", "requiredBy": ["foo"]},
+					{"name": "baz", "version": "1.0", "requester": "transitive", "requiredBy": ["bar"]},
+					{"name": "foo", "version": "1.0", "requester": "project file"}
+				]
+			}`,
+		},
+		{
+			name: "exclude required dependency",
+			opts: &Options{Excludes: []string{"bar"}},
+			lock: `{
+				"plugins": [
+					{"name": "foo", "version": "1.0", "requester": "project file"}
+				]
+			}`,
+			warnings: []string{"the excluded bar plugin is required by foo:1.0"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &api.Graph{}
+			if err := jsonpb.UnmarshalString(graphJSON, g); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			wantLock := &api.PluginsRegistry{}
+			if err := jsonpb.UnmarshalString(tc.lock, wantLock); err != nil {
+				t.Fatalf("%+v", err)
+			}
+
+			lk := newLocker(tc.opts)
+			if err := lk.lock(g); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if got := lk.plugins.newPluginsRegistry(); !proto.Equal(got, wantLock) {
+				t.Errorf("wanted: %s, got: %s", wantLock, got)
+			}
+			if got := lk.sortedWarnings(); !reflect.DeepEqual(got, append([]string{}, tc.warnings...)) {
+				t.Errorf("wanted warnings: %q, got: %q", tc.warnings, got)
+			}
+		})
+	}
+
+	// Requested plugins cannot be overridden nor excluded
+	for _, opts := range []*Options{
+		{Overrides: map[string]string{"foo": "2.0"}},
+		{Excludes: []string{"foo"}},
+	} {
+		g := &api.Graph{}
+		if err := jsonpb.UnmarshalString(graphJSON, g); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := LockPluginsWithOptions(g, opts); err == nil {
+			t.Errorf("not expected to lock the plugins with %+v but it could", opts)
+		}
+	}
+}
+
+func TestLockPluginsStrict(t *testing.T) {
+	testCases := []struct {
+		graph string
+		err   string
+	}{
+		// The pinned mailer plugin would be bumped by a transitive requirement
+		{
+			`{
+				"nodes": [{
+					"plugin": {"name": "foo", "version": "1.0", "requester": "project file"},
+					"dependencies": [{
+						"plugin": {"name": "bar", "version": "2.0"},
+						"dependencies": [{"plugin": {"name": "mailer", "version": "1.7"}}]
+					}]
+				}, {
+					"plugin": {"name": "mailer", "version": "1.6", "requester": "project file"}
+				}]
+			}`,
+			"mailer:1.6 is requested in the project file, but foo:1.0 (project file) > bar:2.0 requires mailer:1.7",
+		},
+		// Lower requirements are fine
+		{
+			`{
+				"nodes": [{
+					"plugin": {"name": "foo", "version": "1.0", "requester": "project file"},
+					"dependencies": [{"plugin": {"name": "mailer", "version": "1.5"}}]
+				}, {
+					"plugin": {"name": "mailer", "version": "1.6", "requester": "project file"}
+				}]
+			}`,
+			"",
+		},
+		// Bundled plugins are not pinned
+		{
+			`{
+				"nodes": [{
+					"plugin": {"name": "foo", "version": "1.0", "requester": "project file"},
+					"dependencies": [{"plugin": {"name": "mailer", "version": "1.7"}}]
+				}, {
+					"plugin": {"name": "mailer", "version": "1.6", "requester": "war"}
+				}]
+			}`,
+			"",
+		},
+	}
+	for _, tc := range testCases {
+		g := &api.Graph{}
+		if err := jsonpb.UnmarshalString(tc.graph, g); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		// The same graph can always be locked if it is not strict
+		if _, err := LockPluginsWithOptions(g, &Options{}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		_, err := LockPluginsWithOptions(g, &Options{Strict: true})
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%+v", err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("expected %q but it could lock the plugins", tc.err)
+		} else if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%q does not contain %q", err.Error(), tc.err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	api "github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/catalog"
+)
+
+// SecurityWarning represents a security warning affecting a locked plugin
+type SecurityWarning struct {
+	Plugin  *api.Plugin
+	Warning *catalog.Warning
+}
+
+// SecurityWarnings is a list of security warnings
+type SecurityWarnings []SecurityWarning
+
+// Print prints a list of security warnings
+func (sws SecurityWarnings) Print() {
+	for nw, sw := range sws {
+		swSep := "├"
+		sep := "│"
+		if nw == len(sws)-1 {
+			swSep = "└"
+			sep = " "
+		}
+		plugin := sw.Plugin.Identifier()
+		// Plugins read from lock files have no requester
+		if sw.Plugin.Requester != "" {
+			plugin = fmt.Sprintf("%s (%s)", plugin, sw.Plugin.Requester)
+		}
+		log.Printf("  %s── Plugin: %s\n", swSep, plugin)
+		log.Printf("  %s   Warning: %s (severity: %s)\n", sep, sw.Warning.ID, sw.Warning.Severity)
+		log.Printf("  %s   Message: %s\n", sep, sw.Warning.Message)
+		log.Printf("  %s     └── %s\n", sep, sw.Warning.URL)
+		log.Printf("  %s\n", sep)
+	}
+}
+
+// FindSecurityWarnings checks the locked plugin versions against the security
+// warnings of a catalog
+func FindSecurityWarnings(lockedPlugins []*api.Plugin, c *catalog.Catalog) SecurityWarnings {
+	plugins := append([]*api.Plugin{}, lockedPlugins...)
+	sort.Sort(api.ByName(plugins))
+
+	warnings := SecurityWarnings{}
+	for _, p := range plugins {
+		for _, w := range c.Warnings(p.Name, p.Version) {
+			warnings = append(warnings, SecurityWarning{Plugin: p, Warning: w})
+		}
+	}
+	return warnings
+}
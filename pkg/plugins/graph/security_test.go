@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"testing"
+
+	api "github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/catalog"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/requesters"
+)
+
+func TestFindSecurityWarnings(t *testing.T) {
+	c, err := catalog.Read("testdata/update-center.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		plugins  []*api.Plugin
+		warnings []string
+	}{
+		{
+			plugins: []*api.Plugin{
+				{Name: "foo", Version: "1.0", Requester: requesters.PROJECT},
+				{Name: "bar", Version: "1.2", Requester: requesters.TRANSITIVE},
+			},
+			warnings: []string{"bar:1.2"},
+		},
+		{
+			plugins: []*api.Plugin{
+				{Name: "foo", Version: "1.0", Requester: requesters.PROJECT},
+				{Name: "bar", Version: "1.3", Requester: requesters.TRANSITIVE},
+			},
+			warnings: []string{},
+		},
+	}
+	for _, tc := range testCases {
+		sws := FindSecurityWarnings(tc.plugins, c)
+		if len(sws) != len(tc.warnings) {
+			t.Fatalf("wanted %d warnings, got: %+v", len(tc.warnings), sws)
+		}
+		for i, sw := range sws {
+			if sw.Plugin.Identifier() != tc.warnings[i] || sw.Warning.ID != "SECURITY-100" || sw.Warning.Severity != "high" {
+				t.Errorf("unexpected warning: %s %+v", sw.Plugin.Identifier(), sw.Warning)
+			}
+		}
+	}
+}
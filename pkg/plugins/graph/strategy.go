@@ -0,0 +1,210 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/catalog"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/requesters"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/juju/errors"
+)
+
+// maxIterations bounds the passes of the iterative strategies
+const maxIterations = 100
+
+// Strategy selects the version of every plugin of a graph
+type Strategy interface {
+	// Lock generates a fully-qualified registry of plugins from a graph
+	Lock(g *api.Graph, opts *Options) (*api.PluginsRegistry, error)
+}
+
+// Strategies are the names of the available strategies (see NewStrategy)
+var Strategies = []string{"max", "minimal", "latest-compatible"}
+
+// ValidStrategy returns an error if there is no strategy with the given name
+func ValidStrategy(name string) error {
+	for _, s := range Strategies {
+		if s == name {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown strategy %q. Available strategies: %s", name, strings.Join(Strategies, ", "))
+}
+
+// NewStrategy returns a strategy by name. The catalog and the Jenkins core version
+// are only used by the latest-compatible strategy.
+func NewStrategy(name string, c *catalog.Catalog, jenkinsVersion string) (Strategy, error) {
+	switch name {
+	case "max":
+		return MaxVersion{}, nil
+	case "minimal":
+		return MinimalVersion{}, nil
+	case "latest-compatible":
+		if c == nil {
+			return nil, errors.Errorf("the %s strategy requires a catalog", name)
+		}
+		return &LatestCompatible{Catalog: c, JenkinsVersion: jenkinsVersion}, nil
+	}
+	return nil, errors.Trace(ValidStrategy(name))
+}
+
+// MaxVersion locks the highest version of every plugin found in the graph, even if
+// it is only required by a version of another plugin that is not locked.
+type MaxVersion struct{}
+
+// Lock implements the Strategy interface
+func (MaxVersion) Lock(g *api.Graph, opts *Options) (*api.PluginsRegistry, error) {
+	lk, err := lockGraph(g, opts, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return lk.registry(), nil
+}
+
+// MinimalVersion locks the lowest versions that satisfy the requirements of the
+// locked plugins: the dependencies of versions that are replaced by newer ones
+// are ignored.
+//
+// Example:
+//   - foo:1.0 depends on bar:2.0, which depends on baz:2.0
+//   - bar:3.0 (requested) depends on baz:1.0
+//
+// MaxVersion locks baz:2.0, while MinimalVersion locks baz:1.0 as bar:2.0 is
+// not installed.
+type MinimalVersion struct{}
+
+// Lock implements the Strategy interface
+func (MinimalVersion) Lock(g *api.Graph, opts *Options) (*api.PluginsRegistry, error) {
+	// Strict violations are only meaningful for the final selection
+	relaxed := *opts
+	relaxed.Strict = false
+
+	var selected map[string]string
+	for i := 0; i < maxIterations; i++ {
+		lk, err := lockGraph(g, &relaxed, selected)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		versions := lk.plugins.versions()
+		if !reflect.DeepEqual(versions, selected) {
+			selected = versions
+			continue
+		}
+		if opts.Strict {
+			if lk, err = lockGraph(g, opts, selected); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		return lk.registry(), nil
+	}
+	return nil, errors.Errorf("unable to find a minimal selection of versions after %d iterations", maxIterations)
+}
+
+// LatestCompatible locks the newest version (in the catalog) of every transitive
+// plugin that is compatible with the Jenkins core. The versions of the plugins
+// requested in the project file and the overridden ones are kept.
+//
+// The newer versions are locked as overrides, so the graph must include their
+// nodes (with the requesters.OVERRIDE requester). Otherwise, it fails with a
+// MissingNodesError listing them.
+type LatestCompatible struct {
+	Catalog *catalog.Catalog
+	// JenkinsVersion is the Jenkins core version. Any version is compatible
+	// with an unknown ("") core.
+	JenkinsVersion string
+}
+
+// MissingNodesError means that a strategy needs nodes that are not in the graph.
+// The graph must be fetched again including the missing plugins.
+type MissingNodesError struct {
+	Plugins []*api.Plugin
+}
+
+func (e *MissingNodesError) Error() string {
+	ids := make([]string, 0, len(e.Plugins))
+	for _, p := range e.Plugins {
+		ids = append(ids, p.Identifier())
+	}
+	return fmt.Sprintf("the graph does not include %s", strings.Join(ids, ", "))
+}
+
+// Lock implements the Strategy interface
+func (s *LatestCompatible) Lock(g *api.Graph, opts *Options) (*api.PluginsRegistry, error) {
+	overrides := map[string]string{}
+	for name, version := range opts.Overrides {
+		overrides[name] = version
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		o := *opts
+		o.Overrides = overrides
+		lk, err := lockGraph(g, &o, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		upgrades, err := s.upgrades(lk.plugins, opts)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(upgrades) == 0 {
+			return lk.registry(), nil
+		}
+
+		missing := []*api.Plugin{}
+		for _, p := range upgrades {
+			overrides[p.Name] = p.Version
+			if !hasNode(g, p) {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, &MissingNodesError{Plugins: missing}
+		}
+	}
+	return nil, errors.Errorf("unable to find the latest compatible versions after %d iterations", maxIterations)
+}
+
+// upgrades returns the locked plugins that have a newer compatible version in the
+// catalog, sorted by name
+func (s *LatestCompatible) upgrades(plugins pluginsMap, opts *Options) ([]*api.Plugin, error) {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	upgrades := []*api.Plugin{}
+	for _, name := range names {
+		e := plugins[name]
+		if _, ok := opts.Overrides[name]; ok || e.requester == requesters.PROJECT {
+			continue
+		}
+		r, err := s.Catalog.Latest(name, s.JenkinsVersion)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if r == nil {
+			continue
+		}
+		if lower, err := utils.VersionLower(e.version, r.Version); err != nil {
+			return nil, errors.Trace(err)
+		} else if lower {
+			upgrades = append(upgrades, &api.Plugin{Name: name, Version: r.Version, Requester: requesters.OVERRIDE})
+		}
+	}
+	return upgrades, nil
+}
+
+// hasNode returns whether the graph includes an override node of a plugin
+func hasNode(g *api.Graph, p *api.Plugin) bool {
+	for _, n := range g.Nodes {
+		if n.Plugin.Requester == requesters.OVERRIDE && n.Plugin.Name == p.Name && n.Plugin.Version == p.Version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/catalog"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestStrategies(t *testing.T) {
+	c, err := catalog.Read("testdata/plugin-versions.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	latest := &LatestCompatible{Catalog: c, JenkinsVersion: "2.440"}
+
+	testCases := []struct {
+		name  string
+		graph string
+		// locks are the expected locks of every strategy
+		locks map[string]string
+	}{
+		{
+			// foo:1.0 requires bar:2.0, which requires baz:2.0, but the requested
+			// bar:3.0 only requires baz:1.0. The graph includes the baz:2.5 node
+			// (the latest version compatible with the core) as an override.
+			name: "superseded requirements",
+			graph: `{
+				"nodes": [{
+					"plugin": {"name": "foo", "version": "1.0", "requester": "project file"},
+					"dependencies": [{
+						"plugin": {"name": "bar", "version": "2.0"},
+						"dependencies": [{"plugin": {"name": "baz", "version": "2.0"}}]
+					}]
+				}, {
+					"plugin": {"name": "bar", "version": "3.0", "requester": "project file"},
+					"dependencies": [{"plugin": {"name": "baz", "version": "1.0"}}]
+				}, {
+					"plugin": {"name": "baz", "version": "2.5", "requester": "override"},
+					"dependencies": [{"plugin": {"name": "qux", "version": "1.0"}}]
+				}]
+			}`,
+			locks: map[string]string{
+				"max": `{
+					"plugins": [
+						{"name": "bar", "version": "3.0", "requester": "project file", "requiredBy": ["foo"]},
+						{"name": "baz", "version": "2.0", "requester": "transitive", "requiredBy": ["bar"]},
+						{"name": "foo", "version": "1.0", "requester": "project file"}
+					]
+				}`,
+				"minimal": `{
+					"plugins": [
+						{"name": "bar", "version": "3.0", "requester": "project file", "requiredBy": ["foo"]},
+						{"name": "baz", "version": "1.0", "requester": "transitive", "requiredBy": ["bar"]},
+						{"name": "foo", "version": "1.0", "requester": "project file"}
+					]
+				}`,
+				"latest-compatible": `{
+					"plugins": [
+						{"name": "bar", "version": "3.0", "requester": "project file", "requiredBy": ["foo"]},
+						{"name": "baz", "version": "2.5", "requester": "transitive", "requiredBy": ["bar"]},
+						{"name": "foo", "version": "1.0", "requester": "project file"},
+						{"name": "qux", "version": "1.0", "requester": "transitive", "requiredBy": ["baz"]}
+					]
+				}`,
+			},
+		},
+		{
+			// The requirements of the superseded baz:1.0 (qux:1.0) are dropped
+			// by the minimal strategy, even if they are only found later on
+			name: "transitive requirements",
+			graph: `{
+				"nodes": [{
+					"plugin": {"name": "foo", "version": "1.0", "requester": "project file"},
+					"dependencies": [{
+						"plugin": {"name": "baz", "version": "1.0"},
+						"dependencies": [{"plugin": {"name": "qux", "version": "1.0"}}]
+					}]
+				}, {
+					"plugin": {"name": "bar", "version": "3.0", "requester": "project file"},
+					"dependencies": [{"plugin": {"name": "baz", "version": "2.5"}}]
+				}]
+			}`,
+			locks: map[string]string{
+				"max": `{
+					"plugins": [
+						{"name": "bar", "version": "3.0", "requester": "project file"},
+						{"name": "baz", "version": "2.5", "requester": "transitive", "requiredBy": ["bar", "foo"]},
+						{"name": "foo", "version": "1.0", "requester": "project file"},
+						{"name": "qux", "version": "1.0", "requester": "transitive", "requiredBy": ["baz"]}
+					]
+				}`,
+				"minimal": `{
+					"plugins": [
+						{"name": "bar", "version": "3.0", "requester": "project file"},
+						{"name": "baz", "version": "2.5", "requester": "transitive", "requiredBy": ["bar", "foo"]},
+						{"name": "foo", "version": "1.0", "requester": "project file"}
+					]
+				}`,
+				"latest-compatible": `{
+					"plugins": [
+						{"name": "bar", "version": "3.0", "requester": "project file"},
+						{"name": "baz", "version": "2.5", "requester": "transitive", "requiredBy": ["bar", "foo"]},
+						{"name": "foo", "version": "1.0", "requester": "project file"},
+						{"name": "qux", "version": "1.0", "requester": "transitive", "requiredBy": ["baz"]}
+					]
+				}`,
+			},
+		},
+	}
+	strategies := map[string]Strategy{
+		"max":               MaxVersion{},
+		"minimal":           MinimalVersion{},
+		"latest-compatible": latest,
+	}
+	for _, tc := range testCases {
+		g := &api.Graph{}
+		if err := jsonpb.UnmarshalString(tc.graph, g); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		for name, s := range strategies {
+			t.Run(tc.name+"/"+name, func(t *testing.T) {
+				want := &api.PluginsRegistry{}
+				if err := jsonpb.UnmarshalString(tc.locks[name], want); err != nil {
+					t.Fatalf("%+v", err)
+				}
+				got, err := LockPluginsWithOptions(g, &Options{Strategy: s})
+				if err != nil {
+					t.Fatalf("%+v", err)
+				}
+				if !proto.Equal(got, want) {
+					t.Errorf("wanted: %s, got: %s", want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestLatestCompatibleMissingNodes(t *testing.T) {
+	c, err := catalog.Read("testdata/plugin-versions.json")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	g := &api.Graph{}
+	if err := jsonpb.UnmarshalString(`{
+		"nodes": [{
+			"plugin": {"name": "foo", "version": "1.0", "requester": "project file"},
+			"dependencies": [{"plugin": {"name": "baz", "version": "1.0"}}]
+		}]
+	}`, g); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		jenkinsVersion string
+		missing        string
+	}{
+		{"2.440", "baz:2.5"},
+		{"", "baz:3.0"},
+	}
+	for _, tc := range testCases {
+		s := &LatestCompatible{Catalog: c, JenkinsVersion: tc.jenkinsVersion}
+		_, err := s.Lock(g, &Options{})
+		e, ok := err.(*MissingNodesError)
+		if !ok {
+			t.Fatalf("wanted a MissingNodesError, got: %v", err)
+		}
+		if len(e.Plugins) != 1 || e.Plugins[0].Identifier() != tc.missing {
+			t.Errorf("wanted %s to be missing, got: %v", tc.missing, e)
+		}
+	}
+}
+
+func TestNewStrategy(t *testing.T) {
+	for _, name := range Strategies {
+		if _, err := NewStrategy(name, &catalog.Catalog{}, ""); err != nil {
+			t.Errorf("%+v", err)
+		}
+		if err := ValidStrategy(name); err != nil {
+			t.Errorf("%+v", err)
+		}
+	}
+	if _, err := NewStrategy("latest-compatible", nil, ""); err == nil {
+		t.Errorf("expected an error without catalog")
+	}
+	if _, err := NewStrategy("foo", nil, ""); err == nil {
+		t.Errorf("expected an error with an unknown strategy")
+	}
+	if err := ValidStrategy("foo"); err == nil {
+		t.Errorf("expected an error with an unknown strategy")
+	}
+}
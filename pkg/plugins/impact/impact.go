@@ -0,0 +1,231 @@
+// Package impact analyzes the impact of upgrading the Jenkins core of a
+// project, so core upgrades can be reviewed before trying them out.
+package impact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/diff"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/war"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/juju/errors"
+)
+
+// MetadataFunc returns the metadata of a plugin
+type MetadataFunc func(p *api.Plugin) (*api.PluginMetadata, error)
+
+// Requirement is a plugin that requires a newer core than the target one
+type Requirement struct {
+	Plugin       string `json:"plugin"`
+	Version      string `json:"version"`
+	RequiredCore string `json:"requiredCore"`
+}
+
+// Implied is a plugin detached from the target core. Plugins built for older
+// cores implicitly depend on it (split plugins).
+type Implied struct {
+	Plugin  string `json:"plugin"`
+	Version string `json:"version"`
+	// Dependents are the locked plugins that will depend on it
+	Dependents []string `json:"dependents"`
+}
+
+// Report is the impact of upgrading the Jenkins core of a project
+type Report struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// NewerCore are the locked plugins that require a newer core than the target one
+	NewerCore []Requirement `json:"newerCore"`
+	// Detached are the changes of the plugins bundled in the core
+	Detached *diff.Diff `json:"detached"`
+	// Implied are the new implied dependencies of split plugins
+	Implied []Implied `json:"implied"`
+	// Upgrades are the changes of the lock file to stay compatible with the target core
+	Upgrades *diff.Diff `json:"upgrades"`
+}
+
+// Analyze compares the lock file of a project for the current core (nil if it is
+// unknown) with the lock file for the target core.
+//
+// The split points of the detached plugins are not recorded in the war manifest,
+// so the plugins detached by the target core are implied dependencies of the
+// locked plugins that require the current core or an older one.
+func Analyze(current *api.Jenkins, target *api.Jenkins, lock *api.PluginsRegistry, targetLock *api.PluginsRegistry, metadata MetadataFunc) (*Report, error) {
+	r := &Report{
+		From:    current.GetVersion(),
+		To:      target.GetVersion(),
+		Implied: []Implied{},
+	}
+
+	// Both lock files are checked, as the upgrades may require newer cores too
+	var err error
+	r.NewerCore, err = NewerCore(append(append([]*api.Plugin{}, lock.GetPlugins()...), targetLock.GetPlugins()...), target.Version, metadata)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if r.Detached, err = diff.Compare(bundled(current), bundled(target)); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if r.Upgrades, err = diff.Compare(lock, targetLock); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The core change is already part of the detached plugins changes
+	r.Upgrades.Jenkins = nil
+
+	if current != nil {
+		for _, c := range r.Detached.Plugins {
+			if c.Kind != diff.Added {
+				continue
+			}
+			dependents, err := impliedDependents(c.Name, current.Version, lock, metadata)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			r.Implied = append(r.Implied, Implied{Plugin: c.Name, Version: c.To, Dependents: dependents})
+		}
+	}
+	return r, nil
+}
+
+// NewerCore returns the plugins that require a newer core than the given one
+// (Jenkins-Version header of the plugins manifest)
+func NewerCore(plugins []*api.Plugin, jenkinsVersion string, metadata MetadataFunc) ([]Requirement, error) {
+	res := []Requirement{}
+	checked := map[string]bool{}
+	for _, p := range plugins {
+		if checked[p.Identifier()] {
+			continue
+		}
+		checked[p.Identifier()] = true
+		pm, err := metadata(p)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if pm.RequiredCore == "" {
+			continue
+		}
+		if newer, err := utils.VersionLower(jenkinsVersion, pm.RequiredCore); err != nil {
+			return nil, errors.Trace(err)
+		} else if newer {
+			res = append(res, Requirement{Plugin: p.Name, Version: p.Version, RequiredCore: pm.RequiredCore})
+		}
+	}
+	return res, nil
+}
+
+// bundled returns the detached plugins of a core (none for unknown cores)
+func bundled(jk *api.Jenkins) *api.PluginsRegistry {
+	if jk == nil {
+		return &api.PluginsRegistry{}
+	}
+	pr := war.NewPluginsRegistry(jk)
+	pr.Jenkins = &api.Core{Version: jk.Version}
+	return pr
+}
+
+// impliedDependents returns the locked plugins that require a core older than (or
+// equal to) the current one and that do not depend on the plugin explicitly
+func impliedDependents(name string, currentVersion string, lock *api.PluginsRegistry, metadata MetadataFunc) ([]string, error) {
+	dependents := []string{}
+	for _, p := range lock.GetPlugins() {
+		if p.Name == name {
+			continue
+		}
+		pm, err := metadata(p)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if pm.RequiredCore == "" || dependsOn(pm, name) {
+			continue
+		}
+		if newer, err := utils.VersionLower(currentVersion, pm.RequiredCore); err != nil {
+			return nil, errors.Trace(err)
+		} else if !newer {
+			dependents = append(dependents, p.Name)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents, nil
+}
+
+func dependsOn(pm *api.PluginMetadata, name string) bool {
+	for _, d := range append(append([]*api.Plugin{}, pm.Dependencies...), pm.OptionalDependencies...) {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJSON writes the report in JSON format
+func (r *Report) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return errors.Trace(e.Encode(r))
+}
+
+// WriteMarkdown writes the report as Markdown sections
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	from := r.From
+	if from == "" {
+		from = "unknown"
+	}
+	fmt.Fprintf(&b, "## Jenkins core %s → %s\n\n", from, r.To)
+
+	b.WriteString("### Plugins requiring a newer core\n\n")
+	if len(r.NewerCore) == 0 {
+		b.WriteString("None.\n\n")
+	} else {
+		b.WriteString("| **Plugin** | **Version** | **Required core** |\n")
+		b.WriteString("| ---------- | ----------- | ----------------- |\n")
+		for _, req := range r.NewerCore {
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", req.Plugin, req.Version, req.RequiredCore)
+		}
+		b.WriteString("\n")
+	}
+
+	if err := writeDiff(&b, "Detached plugins", r.Detached); err != nil {
+		return errors.Trace(err)
+	}
+
+	b.WriteString("### Implied dependencies\n\n")
+	if len(r.Implied) == 0 {
+		b.WriteString("None.\n\n")
+	} else {
+		b.WriteString("| **Plugin** | **Version** | **Dependents** |\n")
+		b.WriteString("| ---------- | ----------- | -------------- |\n")
+		for _, imp := range r.Implied {
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", imp.Plugin, imp.Version, strings.Join(imp.Dependents, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	if err := writeDiff(&b, "Plugin upgrades", r.Upgrades); err != nil {
+		return errors.Trace(err)
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.Trace(err)
+}
+
+// writeDiff writes the plugin changes of a diff as a Markdown section
+func writeDiff(b *strings.Builder, title string, d *diff.Diff) error {
+	fmt.Fprintf(b, "### %s\n\n", title)
+	if len(d.Plugins) == 0 {
+		b.WriteString("None.\n\n")
+		return nil
+	}
+	// The core change is already part of the section title
+	plugins := diff.Diff{Plugins: d.Plugins}
+	if err := plugins.WriteMarkdown(b); err != nil {
+		return errors.Trace(err)
+	}
+	b.WriteString("\n")
+	return nil
+}
@@ -0,0 +1,147 @@
+package impact
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/diff"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+)
+
+func unmarshal(t *testing.T, s string, pb proto.Message) {
+	t.Helper()
+	if err := jsonpb.UnmarshalString(s, pb); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	current := &api.Jenkins{}
+	unmarshal(t, `{
+		"version": "2.426.3",
+		"plugins": [
+			{"plugin": {"name": "mailer", "version": "1.6"}}
+		]
+	}`, current)
+	target := &api.Jenkins{}
+	unmarshal(t, `{
+		"version": "2.462.1",
+		"plugins": [
+			{"plugin": {"name": "mailer", "version": "1.20"}},
+			{"plugin": {"name": "javax-mail-api", "version": "1.6.2-9"}}
+		]
+	}`, target)
+	lock := &api.PluginsRegistry{}
+	unmarshal(t, `{
+		"plugins": [
+			{"name": "git", "version": "5.0"},
+			{"name": "kubernetes", "version": "4029"},
+			{"name": "mailer", "version": "1.6"},
+			{"name": "matrix-auth", "version": "3.0"},
+			{"name": "pipeline-graph-view", "version": "216"}
+		],
+		"jenkins": {"version": "2.426.3"}
+	}`, lock)
+	targetLock := &api.PluginsRegistry{}
+	unmarshal(t, `{
+		"plugins": [
+			{"name": "git", "version": "5.2"},
+			{"name": "javax-mail-api", "version": "1.6.2-9"},
+			{"name": "kubernetes", "version": "4029"},
+			{"name": "mailer", "version": "1.20"},
+			{"name": "matrix-auth", "version": "3.0"},
+			{"name": "pipeline-graph-view", "version": "216"}
+		],
+		"jenkins": {"version": "2.462.1"}
+	}`, targetLock)
+
+	metadata := map[string]*api.PluginMetadata{
+		// git:5.0 is built for an older core, so it implicitly depends on the
+		// detached plugin, while git:5.2 depends on it explicitly
+		"git:5.0":         {RequiredCore: "2.401.3"},
+		"git:5.2":         {RequiredCore: "2.440", Dependencies: []*api.Plugin{{Name: "javax-mail-api", Version: "1.6.2-9"}}},
+		"kubernetes:4029": {RequiredCore: "2.440"},
+		"mailer:1.6":      {RequiredCore: "2.361.4"},
+		"mailer:1.20":     {RequiredCore: "2.440"},
+		// Plugins without required core are ignored
+		"matrix-auth:3.0":         {},
+		"javax-mail-api:1.6.2-9":  {RequiredCore: "2.361.4"},
+		"pipeline-graph-view:216": {RequiredCore: "2.479.1"},
+	}
+	lookup := func(p *api.Plugin) (*api.PluginMetadata, error) {
+		pm, ok := metadata[p.Identifier()]
+		if !ok {
+			return nil, errors.Errorf("unexpected %s plugin", p.Identifier())
+		}
+		return pm, nil
+	}
+
+	r, err := Analyze(current, target, lock, targetLock, lookup)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if want := []Requirement{{Plugin: "pipeline-graph-view", Version: "216", RequiredCore: "2.479.1"}}; !reflect.DeepEqual(r.NewerCore, want) {
+		t.Errorf("wanted: %+v, got: %+v", want, r.NewerCore)
+	}
+	wantDetached := &diff.Diff{
+		Jenkins: &diff.Change{Name: "jenkins", Kind: diff.Upgraded, From: "2.426.3", To: "2.462.1"},
+		Plugins: []diff.Change{
+			{Name: "javax-mail-api", Kind: diff.Added, To: "1.6.2-9"},
+			{Name: "mailer", Kind: diff.Upgraded, From: "1.6", To: "1.20"},
+		},
+	}
+	if !reflect.DeepEqual(r.Detached, wantDetached) {
+		t.Errorf("wanted: %+v, got: %+v", wantDetached, r.Detached)
+	}
+	if want := []Implied{{Plugin: "javax-mail-api", Version: "1.6.2-9", Dependents: []string{"git", "mailer"}}}; !reflect.DeepEqual(r.Implied, want) {
+		t.Errorf("wanted: %+v, got: %+v", want, r.Implied)
+	}
+	wantUpgrades := &diff.Diff{
+		Plugins: []diff.Change{
+			{Name: "git", Kind: diff.Upgraded, From: "5.0", To: "5.2"},
+			{Name: "javax-mail-api", Kind: diff.Added, To: "1.6.2-9"},
+			{Name: "mailer", Kind: diff.Upgraded, From: "1.6", To: "1.20"},
+		},
+	}
+	if !reflect.DeepEqual(r.Upgrades, wantUpgrades) {
+		t.Errorf("wanted: %+v, got: %+v", wantUpgrades, r.Upgrades)
+	}
+
+	var b bytes.Buffer
+	if err := r.WriteMarkdown(&b); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, s := range []string{
+		"## Jenkins core 2.426.3 → 2.462.1",
+		"| `pipeline-graph-view` | 216 | 2.479.1 |",
+		"| `javax-mail-api` | 1.6.2-9 | git, mailer |",
+		"| `git` | upgraded | 5.0 | 5.2 |",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("%q does not contain %q", b.String(), s)
+		}
+	}
+}
+
+func TestAnalyzeUnknownCore(t *testing.T) {
+	target := &api.Jenkins{Version: "2.462.1", Plugins: []*api.PluginMetadata{
+		{Plugin: &api.Plugin{Name: "mailer", Version: "1.20"}},
+	}}
+	lookup := func(p *api.Plugin) (*api.PluginMetadata, error) {
+		return &api.PluginMetadata{}, nil
+	}
+	r, err := Analyze(nil, target, &api.PluginsRegistry{}, &api.PluginsRegistry{}, lookup)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	// The implied dependencies are unknown without the current core
+	if r.From != "" || len(r.Detached.Plugins) != 1 || len(r.Implied) != 0 {
+		t.Errorf("unexpected report: %+v", r)
+	}
+}
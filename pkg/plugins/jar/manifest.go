@@ -0,0 +1,126 @@
+package jar
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/juju/errors"
+)
+
+const (
+	// Manifest lines can not be longer than 72 bytes (excluding the line break).
+	// Longer values continue in the next line with a leading space.
+	maxLineLength = 72
+	lineBreak     = "\r\n"
+)
+
+// Attribute represents a manifest "Key: Value" entry
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Section represents an ordered list of manifest attributes
+type Section []Attribute
+
+// Get returns the value of the given attribute (or an empty string if it is missing)
+func (s Section) Get(key string) string {
+	for _, a := range s {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Set updates the value of the given attribute or appends it if it is missing
+func (s *Section) Set(key string, value string) {
+	for i, a := range *s {
+		if a.Key == key {
+			(*s)[i].Value = value
+			return
+		}
+	}
+	*s = append(*s, Attribute{Key: key, Value: value})
+}
+
+// Manifest represents a jar manifest file: the main section followed by the
+// per-entry sections (those starting with a "Name" attribute).
+type Manifest struct {
+	Main     Section
+	Sections []Section
+}
+
+// ParseManifest parses the content of a MANIFEST.MF file
+func ParseManifest(manifest string) (*Manifest, error) {
+	m := &Manifest{}
+
+	// Continuation lines start with a single space and belong to the
+	// previous line.
+	manifest = strings.ReplaceAll(manifest, "\r\n", "\n")
+	manifest = strings.ReplaceAll(manifest, "\n ", "")
+
+	var section Section
+	main := true
+	flush := func() {
+		if main {
+			m.Main = section
+			main = false
+		} else if len(section) > 0 {
+			m.Sections = append(m.Sections, section)
+		}
+		section = nil
+	}
+	for _, line := range strings.Split(manifest, "\n") {
+		if line == "" {
+			flush()
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("malformed manifest line %q", line)
+		}
+		section = append(section, Attribute{Key: kv[0], Value: strings.TrimPrefix(kv[1], " ")})
+	}
+	flush()
+
+	return m, nil
+}
+
+// Section returns the section for the given entry name (or nil if it is missing)
+func (m *Manifest) Section(name string) *Section {
+	for i, s := range m.Sections {
+		if s.Get("Name") == name {
+			return &m.Sections[i]
+		}
+	}
+	return nil
+}
+
+// String serializes the manifest following the jar specification
+func (m *Manifest) String() string {
+	var sb strings.Builder
+	for _, s := range append([]Section{m.Main}, m.Sections...) {
+		for _, a := range s {
+			writeLine(&sb, a.Key+": "+a.Value)
+		}
+		sb.WriteString(lineBreak)
+	}
+	return sb.String()
+}
+
+func writeLine(sb *strings.Builder, line string) {
+	for len(line) > maxLineLength {
+		// Multi-byte characters are not split across lines
+		n := maxLineLength
+		for n > 1 && !utf8.RuneStart(line[n]) {
+			n--
+		}
+		sb.WriteString(line[:n])
+		sb.WriteString(lineBreak)
+		// The leading space of the continuation line counts as part of it
+		line = " " + line[n:]
+	}
+	sb.WriteString(line)
+	sb.WriteString(lineBreak)
+}
@@ -0,0 +1,91 @@
+package jar
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseManifest(t *testing.T) {
+	testCases := []struct {
+		manifest string
+		main     Section
+		sections []Section
+	}{
+		{
+			"Manifest-Version: 1.0\r\nJenkins-Version: 2.176.3\r\n\r\nName: foo.txt\r\nSHA-256-Digest: abc\r\n\r\n",
+			Section{{"Manifest-Version", "1.0"}, {"Jenkins-Version", "2.176.3"}},
+			[]Section{{{"Name", "foo.txt"}, {"SHA-256-Digest", "abc"}}},
+		},
+		// Continuation lines belong to the previous attribute
+		{
+			"Manifest-Version: 1.0\r\nPlugin-Dependencies: foo:1.0,\r\n bar:2.0\r\n",
+			Section{{"Manifest-Version", "1.0"}, {"Plugin-Dependencies", "foo:1.0,bar:2.0"}},
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		m, err := ParseManifest(tc.manifest)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		want := (&Manifest{Main: tc.main, Sections: tc.sections}).String()
+		if got := m.String(); got != want {
+			t.Errorf("wanted: %q, got: %q", want, got)
+		}
+	}
+}
+
+func TestManifestString(t *testing.T) {
+	m := &Manifest{
+		Main: Section{{"Manifest-Version", "1.0"}},
+	}
+	m.Sections = append(m.Sections, Section{{"Name", strings.Repeat("a", 100)}})
+	m.Main.Set("Jenkins-Version", "2.176.3")
+
+	got := m.String()
+	for _, line := range strings.Split(got, "\r\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("line %q is longer than %d bytes", line, maxLineLength)
+		}
+	}
+
+	// Serializing the manifest must be reversible
+	parsed, err := ParseManifest(got)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if parsed.String() != got {
+		t.Errorf("wanted: %q, got: %q", got, parsed.String())
+	}
+	if name := parsed.Sections[0].Get("Name"); name != strings.Repeat("a", 100) {
+		t.Errorf("wanted a 100 characters name, got: %q", name)
+	}
+	if version := parsed.Main.Get("Jenkins-Version"); version != "2.176.3" {
+		t.Errorf("wanted: 2.176.3, got: %q", version)
+	}
+}
+
+func TestManifestStringUTF8(t *testing.T) {
+	// Every line break would split a multi-byte character at a fixed 72 bytes width
+	longName := "Plugin " + strings.Repeat("ñandú ", 30)
+	m := &Manifest{Main: Section{{"Manifest-Version", "1.0"}, {"Long-Name", longName}}}
+
+	got := m.String()
+	for _, line := range strings.Split(got, "\r\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("line %q is longer than %d bytes", line, maxLineLength)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %q splits a multi-byte character", line)
+		}
+	}
+	parsed, err := ParseManifest(got)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if name := parsed.Main.Get("Long-Name"); name != longName {
+		t.Errorf("wanted: %q, got: %q", longName, name)
+	}
+}
@@ -13,6 +13,7 @@ var (
 	shortNameRegex    = regexp.MustCompile(`Short-Name:\s*([^\r]+)\r?\n`)
 	fullNameRegex     = regexp.MustCompile(`Long-Name:\s*([^\r]+)\r?\n`)
 	dependenciesRegex = regexp.MustCompile(`Plugin-Dependencies:\s*([^\r]+)\r?\n`)
+	requiredCoreRegex = regexp.MustCompile(`Jenkins-Version:\s*([^\r]+)\r?\n`)
 	dependencyRegex   = regexp.MustCompile(`([^:]+):([^;]+)(;(.*))?`)
 )
 
@@ -49,6 +50,9 @@ func ParseManifest(manifest string) (*api.PluginMetadata, error) {
 		}
 	}
 
+	// NOTE: Plugins built for very old cores miss the Jenkins-Version field
+	requiredCore, _ := findMatch(requiredCoreRegex, manifest)
+
 	pm := api.PluginMetadata{
 		FullName: fullName,
 		Plugin: &api.Plugin{
@@ -57,6 +61,7 @@ func ParseManifest(manifest string) (*api.PluginMetadata, error) {
 		},
 		Dependencies:         dependencies,
 		OptionalDependencies: optionalDependencies,
+		RequiredCore:         requiredCore,
 	}
 
 	return &pm, nil
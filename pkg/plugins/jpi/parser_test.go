@@ -31,6 +31,13 @@ func TestParseManifest(t *testing.T) {
 				FullName: "Foo",
 			},
 		},
+		{"Plugin-Version: 1.2.3\r\nShort-Name: foo\r\nLong-Name: Foo\r\nJenkins-Version: 2.426.3\r\n",
+			&api.PluginMetadata{
+				Plugin:       &api.Plugin{Name: "foo", Version: "1.2.3"},
+				FullName:     "Foo",
+				RequiredCore: "2.426.3",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
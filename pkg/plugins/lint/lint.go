@@ -0,0 +1,180 @@
+// Package lint finds requested plugins of a project file that are redundant with
+// its lock file, so project files only list the plugins they really need.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/diff"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/impact"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/juju/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Kinds of findings
+const (
+	// Redundant entries are required by other locked plugins at the same or a
+	// higher version
+	Redundant = "redundant"
+	// PinnedLower entries are requested at a lower version than the locked one
+	PinnedLower = "pinned-lower"
+	// OptionalOnly entries are only optional dependencies of other locked plugins
+	OptionalOnly = "optional-only"
+)
+
+// Finding is a requested plugin that the project file may not need (as is)
+type Finding struct {
+	Plugin    string   `json:"plugin"`
+	Requested string   `json:"requested"`
+	Locked    string   `json:"locked"`
+	Kinds     []string `json:"kinds"`
+	// Dependents are the locked plugins that depend on it
+	Dependents []string `json:"dependents"`
+}
+
+// Report is the lint of the requested plugins of a project
+type Report struct {
+	Findings []*Finding `json:"findings"`
+	// Minimal are the requested plugins of the suggested minimal project file
+	Minimal map[string]string `json:"minimal"`
+	// Changes are the changes of the lock file of the minimal project file, which
+	// should be empty. See Verify.
+	Changes *diff.Diff `json:"changes,omitempty"`
+}
+
+// Analyze lints the requested plugins of a project against its lock file. The
+// optional dependencies of the locked plugins are only requirements if they are
+// locked too (optional).
+func Analyze(project *api.Project, lock *api.PluginsRegistry, metadata impact.MetadataFunc, optional bool) (*Report, error) {
+	locked := map[string]string{}
+	for _, p := range lock.GetPlugins() {
+		locked[p.Name] = p.Version
+	}
+
+	// requirements are the versions of every plugin required by the locked plugins
+	requirements := map[string][]requirement{}
+	for _, p := range lock.GetPlugins() {
+		pm, err := metadata(p)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, d := range pm.Dependencies {
+			requirements[d.Name] = append(requirements[d.Name], requirement{p.Identifier(), d.Version, false})
+		}
+		for _, d := range pm.OptionalDependencies {
+			requirements[d.Name] = append(requirements[d.Name], requirement{p.Identifier(), d.Version, true})
+		}
+	}
+
+	names := make([]string, 0, len(project.GetDependencies()))
+	for name := range project.GetDependencies() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r := &Report{Findings: []*Finding{}, Minimal: map[string]string{}}
+	for _, name := range names {
+		version, ok := locked[name]
+		if !ok {
+			// Excluded plugins are not locked
+			r.Minimal[name] = project.Dependencies[name]
+			continue
+		}
+		f := &Finding{Plugin: name, Requested: project.Dependencies[name], Locked: version, Dependents: []string{}}
+		if lower, err := utils.VersionLower(f.Requested, version); err != nil {
+			return nil, errors.Trace(err)
+		} else if lower {
+			f.Kinds = append(f.Kinds, PinnedLower)
+		}
+
+		required, onlyOptional := false, true
+		for _, req := range requirements[name] {
+			f.Dependents = append(f.Dependents, req.requester)
+			if req.optional && !optional {
+				continue
+			}
+			onlyOptional = false
+			if lower, err := utils.VersionLower(req.version, version); err != nil {
+				return nil, errors.Trace(err)
+			} else if !lower {
+				required = true
+			}
+		}
+		sort.Strings(f.Dependents)
+		switch {
+		case required:
+			f.Kinds = append(f.Kinds, Redundant)
+		case len(f.Dependents) > 0 && onlyOptional:
+			f.Kinds = append(f.Kinds, OptionalOnly)
+		}
+
+		if !required {
+			// Pinned entries are bumped to the locked version
+			r.Minimal[name] = version
+		}
+		if len(f.Kinds) > 0 {
+			r.Findings = append(r.Findings, f)
+		}
+	}
+	return r, nil
+}
+
+// requirement is a dependency of a locked plugin
+type requirement struct {
+	requester string
+	version   string
+	optional  bool
+}
+
+// Verify records the changes from the lock file of the project to the lock file
+// of the minimal project file
+func (r *Report) Verify(lock *api.PluginsRegistry, minimalLock *api.PluginsRegistry) error {
+	var err error
+	r.Changes, err = diff.Compare(lock, minimalLock)
+	return errors.Trace(err)
+}
+
+// WriteJSON writes the report in JSON format
+func (r *Report) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return errors.Trace(e.Encode(r))
+}
+
+// WriteMarkdown writes the report as Markdown sections
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("## Lint\n\n")
+	if len(r.Findings) == 0 {
+		b.WriteString("No findings.\n\n")
+	} else {
+		b.WriteString("| **Plugin** | **Requested** | **Locked** | **Finding** | **Dependents** |\n")
+		b.WriteString("| ---------- | ------------- | ---------- | ----------- | -------------- |\n")
+		for _, f := range r.Findings {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n", f.Plugin, f.Requested, f.Locked, strings.Join(f.Kinds, ", "), strings.Join(f.Dependents, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("### Minimal project file\n\n")
+	if r.Changes != nil && !r.Changes.Empty() {
+		b.WriteString("The minimal project file does not yield an identical lock file:\n\n")
+		if err := r.Changes.WriteMarkdown(&b); err != nil {
+			return errors.Trace(err)
+		}
+		b.WriteString("\n")
+	}
+	data, err := yaml.Marshal(map[string]map[string]string{"dependencies": r.Minimal})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	fmt.Fprintf(&b, "```yaml\n%s```\n", data)
+	_, err = io.WriteString(w, b.String())
+	return errors.Trace(err)
+}
@@ -0,0 +1,136 @@
+package lint
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/juju/errors"
+)
+
+func TestAnalyze(t *testing.T) {
+	project := &api.Project{Dependencies: map[string]string{
+		"git":         "5.2",
+		"git-client":  "4.0",
+		"mailer":      "1.6",
+		"credentials": "1.0",
+		"matrix-auth": "3.0",
+		"excluded":    "1.0",
+	}}
+	lock := &api.PluginsRegistry{}
+	if err := jsonpb.UnmarshalString(`{
+		"plugins": [
+			{"name": "credentials", "version": "1.2"},
+			{"name": "git", "version": "5.2"},
+			{"name": "git-client", "version": "4.6"},
+			{"name": "mailer", "version": "1.6"},
+			{"name": "matrix-auth", "version": "3.0"}
+		]
+	}`, lock); err != nil {
+		t.Fatal(err)
+	}
+	metadata := map[string]*api.PluginMetadata{
+		"credentials:1.2": {},
+		"git:5.2": {
+			Dependencies: []*api.Plugin{
+				{Name: "git-client", Version: "4.6"},
+				{Name: "credentials", Version: "1.1"},
+			},
+			OptionalDependencies: []*api.Plugin{{Name: "matrix-auth", Version: "2.0"}},
+		},
+		"git-client:4.6":  {Dependencies: []*api.Plugin{{Name: "mailer", Version: "1.5"}}},
+		"mailer:1.6":      {},
+		"matrix-auth:3.0": {},
+	}
+	lookup := func(p *api.Plugin) (*api.PluginMetadata, error) {
+		pm, ok := metadata[p.Identifier()]
+		if !ok {
+			return nil, errors.Errorf("unexpected %s plugin", p.Identifier())
+		}
+		return pm, nil
+	}
+
+	testCases := []struct {
+		optional bool
+		findings map[string][]string
+		minimal  map[string]string
+	}{
+		{
+			optional: false,
+			findings: map[string][]string{
+				// credentials is required by git, but at a lower version
+				"credentials": {PinnedLower},
+				"git-client":  {PinnedLower, Redundant},
+				"matrix-auth": {OptionalOnly},
+			},
+			minimal: map[string]string{
+				"credentials": "1.2",
+				"excluded":    "1.0",
+				"git":         "5.2",
+				"mailer":      "1.6",
+				"matrix-auth": "3.0",
+			},
+		},
+		{
+			// The optional dependencies are requirements too
+			optional: true,
+			findings: map[string][]string{
+				"credentials": {PinnedLower},
+				"git-client":  {PinnedLower, Redundant},
+			},
+			minimal: map[string]string{
+				"credentials": "1.2",
+				"excluded":    "1.0",
+				"git":         "5.2",
+				"mailer":      "1.6",
+				"matrix-auth": "3.0",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		r, err := Analyze(project, lock, lookup, tc.optional)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		findings := map[string][]string{}
+		for _, f := range r.Findings {
+			findings[f.Plugin] = f.Kinds
+		}
+		if !reflect.DeepEqual(findings, tc.findings) {
+			t.Errorf("optional %v: wanted: %v, got: %v", tc.optional, tc.findings, findings)
+		}
+		if !reflect.DeepEqual(r.Minimal, tc.minimal) {
+			t.Errorf("optional %v: wanted: %v, got: %v", tc.optional, tc.minimal, r.Minimal)
+		}
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	r := &Report{
+		Findings: []*Finding{{Plugin: "git-client", Requested: "4.0", Locked: "4.6", Kinds: []string{PinnedLower, Redundant}, Dependents: []string{"git:5.2"}}},
+		Minimal:  map[string]string{"git": "5.2", "mailer": "1.20"},
+	}
+	old := &api.PluginsRegistry{Plugins: []*api.Plugin{{Name: "mailer", Version: "1.20"}}}
+	if err := r.Verify(old, old); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var b bytes.Buffer
+	if err := r.WriteMarkdown(&b); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, s := range []string{
+		"| `git-client` | 4.0 | 4.6 | pinned-lower, redundant | git:5.2 |",
+		"```yaml\ndependencies:\n  git: \"5.2\"\n  mailer: \"1.20\"\n```\n",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("%q does not contain %q", b.String(), s)
+		}
+	}
+	if strings.Contains(b.String(), "does not yield") {
+		t.Errorf("%q should not report lock file changes", b.String())
+	}
+}
@@ -0,0 +1,175 @@
+// Package matrix reports the resolution of a project for several Jenkins cores,
+// so it is easy to tell which cores a project file is safe to roll out to.
+package matrix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/diff"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/graph"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/impact"
+	"github.com/juju/errors"
+)
+
+// Statuses of a resolution
+const (
+	OK           = "ok"
+	Incompatible = "incompatible"
+	Failed       = "failed"
+)
+
+// Incompatibility is a requested plugin that other plugins require a newer version of
+type Incompatibility struct {
+	Plugin     string   `json:"plugin"`
+	Requester  string   `json:"requester"`
+	Cause      string   `json:"cause"`
+	Requesters []string `json:"requesters"`
+}
+
+// Result is the resolution of a project for a Jenkins core
+type Result struct {
+	Core   string `json:"core"`
+	Status string `json:"status"`
+	// Error is the reason why the resolution failed (if it did)
+	Error string `json:"error,omitempty"`
+	// Changes are the changes of the lock file from the baseline one
+	Changes           *diff.Diff        `json:"changes,omitempty"`
+	Incompatibilities []Incompatibility `json:"incompatibilities"`
+	// NewerCore are the locked plugins that require a newer core
+	NewerCore []impact.Requirement `json:"newerCore"`
+}
+
+// Matrix is the resolution of a project for several Jenkins cores
+type Matrix struct {
+	// Baseline is the first core the project could be resolved for. The lock
+	// file changes of the rest of cores are relative to its lock file.
+	Baseline string    `json:"baseline"`
+	Results  []*Result `json:"results"`
+	// Metadata reads the metadata of the locked plugins to check the core they
+	// require. The requirements are not checked if it is nil.
+	Metadata impact.MetadataFunc `json:"-"`
+
+	baselineLock *api.PluginsRegistry
+}
+
+// Add records the resolution of the project for a core: its lock file and
+// incompatibilities, or the error if the resolution failed. The locked plugins
+// that require a newer core than the lock file one make the core incompatible too.
+func (m *Matrix) Add(core string, lock *api.PluginsRegistry, incs graph.Incompatibilities, err error) error {
+	r := &Result{Core: core, Status: OK, Incompatibilities: []Incompatibility{}, NewerCore: []impact.Requirement{}}
+	m.Results = append(m.Results, r)
+	if err != nil {
+		r.Status = Failed
+		r.Error = err.Error()
+		return nil
+	}
+
+	for _, inc := range incs {
+		r.Incompatibilities = append(r.Incompatibilities, Incompatibility{
+			Plugin:     inc.Plugin.Identifier(),
+			Requester:  inc.Plugin.Requester,
+			Cause:      inc.Cause,
+			Requesters: inc.Requesters,
+		})
+	}
+	if m.Metadata != nil {
+		if r.NewerCore, err = impact.NewerCore(lock.GetPlugins(), lock.GetJenkins().GetVersion(), m.Metadata); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(incs) > 0 || len(r.NewerCore) > 0 {
+		r.Status = Incompatible
+	}
+
+	if m.baselineLock == nil {
+		m.Baseline = core
+		m.baselineLock = lock
+	}
+	if r.Changes, err = diff.Compare(m.baselineLock, lock); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// Failed returns the number of cores the project could not be resolved for
+func (m *Matrix) Failed() int {
+	failed := 0
+	for _, r := range m.Results {
+		if r.Status == Failed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteJSON writes the matrix in JSON format
+func (m *Matrix) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	// Keep the requesters trees readable
+	e.SetEscapeHTML(false)
+	return errors.Trace(e.Encode(m))
+}
+
+// WriteMarkdown writes the matrix as a Markdown table followed by the details of
+// every core
+func (m *Matrix) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("## Compatibility matrix\n\n")
+	b.WriteString("| **Core** | **Status** | **Lock changes** | **Incompatibilities** | **Newer core required** |\n")
+	b.WriteString("| -------- | ---------- | ---------------- | --------------------- | ----------------------- |\n")
+	for _, r := range m.Results {
+		changes := ""
+		switch {
+		case r.Status == Failed:
+		case r.Core == m.Baseline:
+			changes = "baseline"
+		default:
+			changes = r.Changes.Summary()
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %d |\n", r.Core, r.Status, changes, len(r.Incompatibilities), len(r.NewerCore))
+	}
+	b.WriteString("\n")
+
+	for _, r := range m.Results {
+		if r.Status == OK && (r.Changes == nil || len(r.Changes.Plugins) == 0) {
+			continue
+		}
+		fmt.Fprintf(&b, "### %s\n\n", r.Core)
+		if r.Status == Failed {
+			fmt.Fprintf(&b, "```\n%s\n```\n\n", r.Error)
+			continue
+		}
+		if len(r.Changes.Plugins) > 0 {
+			// The core change is already part of the section title
+			plugins := diff.Diff{Plugins: r.Changes.Plugins}
+			if err := plugins.WriteMarkdown(&b); err != nil {
+				return errors.Trace(err)
+			}
+			b.WriteString("\n")
+		}
+		if len(r.Incompatibilities) > 0 {
+			b.WriteString("**Incompatibilities**:\n\n")
+			for _, inc := range r.Incompatibilities {
+				fmt.Fprintf(&b, "- `%s` (%s): %s\n", inc.Plugin, inc.Requester, inc.Cause)
+				for _, req := range inc.Requesters {
+					fmt.Fprintf(&b, "  - %s\n", req)
+				}
+			}
+			b.WriteString("\n")
+		}
+		if len(r.NewerCore) > 0 {
+			b.WriteString("**Plugins requiring a newer core**:\n\n")
+			for _, req := range r.NewerCore {
+				fmt.Fprintf(&b, "- `%s:%s` requires jenkins:%s\n", req.Plugin, req.Version, req.RequiredCore)
+			}
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.Trace(err)
+}
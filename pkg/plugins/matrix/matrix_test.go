@@ -0,0 +1,129 @@
+package matrix
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/diff"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/graph"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/requesters"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/juju/errors"
+)
+
+func unmarshal(t *testing.T, s string) *api.PluginsRegistry {
+	t.Helper()
+	pr := &api.PluginsRegistry{}
+	if err := jsonpb.UnmarshalString(s, pr); err != nil {
+		t.Fatal(err)
+	}
+	return pr
+}
+
+func TestMatrix(t *testing.T) {
+	requiredCores := map[string]string{
+		"git:5.0":    "2.426.3",
+		"git:5.2":    "2.440.3",
+		"mailer:1.6": "2.361.4",
+	}
+	m := &Matrix{
+		Metadata: func(p *api.Plugin) (*api.PluginMetadata, error) {
+			return &api.PluginMetadata{Plugin: p, RequiredCore: requiredCores[p.Identifier()]}, nil
+		},
+	}
+	testCases := []struct {
+		core string
+		lock *api.PluginsRegistry
+		incs graph.Incompatibilities
+		err  error
+	}{
+		{
+			core: "2.387.3",
+			err:  errors.New("found bundled plugin mailer:1.20: it is higher than the requested mailer:1.6 plugin"),
+		},
+		{
+			core: "2.426.3",
+			lock: unmarshal(t, `{
+				"plugins": [
+					{"name": "git", "version": "5.0"},
+					{"name": "mailer", "version": "1.6"}
+				],
+				"jenkins": {"version": "2.426.3"}
+			}`),
+		},
+		{
+			core: "2.462.1",
+			lock: unmarshal(t, `{
+				"plugins": [
+					{"name": "git", "version": "5.2"},
+					{"name": "javax-mail-api", "version": "1.6.2-9"},
+					{"name": "mailer", "version": "1.20"}
+				],
+				"jenkins": {"version": "2.462.1"}
+			}`),
+			incs: graph.Incompatibilities{{
+				Plugin:     &api.Plugin{Name: "mailer", Version: "1.6", Requester: requesters.PROJECT},
+				Cause:      "Some plugins require a newer version.",
+				Requesters: []string{"git:5.2 (project file) > mailer:1.20"},
+			}},
+		},
+		{
+			// The locked git version requires a newer core
+			core: "2.414.3",
+			lock: unmarshal(t, `{
+				"plugins": [
+					{"name": "git", "version": "5.0"},
+					{"name": "mailer", "version": "1.6"}
+				],
+				"jenkins": {"version": "2.414.3"}
+			}`),
+		},
+	}
+	for _, tc := range testCases {
+		if err := m.Add(tc.core, tc.lock, tc.incs, tc.err); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	if m.Baseline != "2.426.3" {
+		t.Errorf("wanted 2.426.3 baseline, got %q", m.Baseline)
+	}
+	if got := m.Failed(); got != 1 {
+		t.Errorf("wanted 1 failed core, got %d", got)
+	}
+	for i, want := range []string{Failed, OK, Incompatible, Incompatible} {
+		if got := m.Results[i].Status; got != want {
+			t.Errorf("%s: wanted %q status, got %q", m.Results[i].Core, want, got)
+		}
+	}
+	if got := m.Results[1].Changes; len(got.Plugins) != 0 || got.Jenkins != nil {
+		t.Errorf("the baseline lock should not change, got %+v", got)
+	}
+	if got := m.Results[2].Changes.Plugins; len(got) != 3 || got[1].Kind != diff.Added {
+		t.Errorf("unexpected changes: %+v", got)
+	}
+
+	var b bytes.Buffer
+	if err := m.WriteMarkdown(&b); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, s := range []string{
+		"| 2.387.3 | failed |  | 0 | 0 |",
+		"| 2.426.3 | ok | baseline | 0 | 0 |",
+		"| 2.462.1 | incompatible | 1 added, 0 removed, 2 upgraded, 0 downgraded | 1 | 0 |",
+		"| 2.414.3 | incompatible | 0 added, 0 removed, 0 upgraded, 0 downgraded | 0 | 1 |",
+		"- `git:5.0` requires jenkins:2.426.3",
+		"found bundled plugin mailer:1.20",
+		"- `mailer:1.6` (project file): Some plugins require a newer version.",
+		"  - git:5.2 (project file) > mailer:1.20",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("%q does not contain %q", b.String(), s)
+		}
+	}
+	if strings.Contains(b.String(), "### 2.426.3") {
+		t.Errorf("%q should not detail the baseline core", b.String())
+	}
+}
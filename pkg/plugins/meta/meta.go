@@ -12,6 +12,10 @@ import (
 	"github.com/mkmik/multierror"
 )
 
+// metaSchema is the version of the metadata format. It must be bumped whenever
+// the way metadata is parsed changes, so stored metadata gets invalidated.
+const metaSchema = 1
+
 // WriteMetadata will write the plugin metadata into a file
 func WriteMetadata(pm *api.PluginMetadata, metaPath string) error {
 	return utils.MarshalJSON(metaPath, pm)
@@ -41,7 +45,11 @@ func FetchMetadata(p *api.Plugin, d common.Downloader, workingDir string) error
 	}
 
 	if cached {
-		return nil
+		pm, err := ReadMetadata(metaPath)
+		if err == nil && pm.Schema >= metaSchema {
+			return nil
+		}
+		log.Printf("Ignoring stale metadata %s\n", metaPath)
 	}
 
 	log.Printf("> fetching %s metadata...\n", p.Identifier())
@@ -60,6 +68,7 @@ func FetchMetadata(p *api.Plugin, d common.Downloader, workingDir string) error
 		return errors.Trace(err)
 	}
 
+	pm.Schema = metaSchema
 	if err := WriteMetadata(pm, metaPath); err != nil {
 		return errors.Trace(err)
 	}
@@ -5,4 +5,9 @@ const (
 	WAR = "war"
 	// PROJECT means that the requester was the project file (input)
 	PROJECT = "project file"
+	// TRANSITIVE means that the requester was another plugin (dependency)
+	TRANSITIVE = "transitive"
+	// OVERRIDE means that the requester was an override in the project file.
+	// Overridden plugins are only locked if other plugins depend on them.
+	OVERRIDE = "override"
 )
@@ -0,0 +1,258 @@
+package war
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jar"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jpi"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/google/renameio"
+	"github.com/juju/errors"
+)
+
+const (
+	bundledPluginsDir  = "WEB-INF/plugins/"
+	detachedPluginsDir = "WEB-INF/detached-plugins/"
+	digestAttribute    = "SHA-256-Digest"
+)
+
+var (
+	// All the entries of a bundle share the same modification time so the
+	// output only depends on the inputs (reproducible builds).
+	bundleModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// bundleEntry represents an entry of the output war. It is either copied
+// from the input war (raw) or read from a file in the filesystem.
+type bundleEntry struct {
+	name string
+	zf   *zip.File
+	file string
+}
+
+// Bundle writes a new war file to `output` based on the `warfile` one with
+// the locked plugins embedded under WEB-INF/plugins. The detached plugins that
+// are superseded by the lock are replaced too.
+//
+// The locked plugins must be already present in the jpi store (see jpi.RunWorkersPoll).
+func Bundle(warfile string, lock *api.PluginsRegistry, workingDir string, output string) error {
+	zr, err := zip.OpenReader(warfile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer zr.Close()
+
+	entries := map[string]*bundleEntry{}
+	for _, zf := range zr.File {
+		if isSignatureFile(zf.Name) {
+			log.Printf("removing war signature file %s: the bundle content differs from the signed one\n", zf.Name)
+			continue
+		}
+		entries[zf.Name] = &bundleEntry{name: zf.Name, zf: zf}
+	}
+
+	data, err := readEntry(entries[manifestPath])
+	if err != nil {
+		return errors.Annotatef(err, "unable to read %s from %s", manifestPath, warfile)
+	}
+	manifest, err := jar.ParseManifest(string(data))
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	entries[bundledPluginsDir] = &bundleEntry{name: bundledPluginsDir}
+	for _, p := range lock.Plugins {
+		src := jpi.GetPluginPath(p, workingDir)
+		if ok, err := utils.FileExists(src); err != nil {
+			return errors.Trace(err)
+		} else if !ok {
+			return errors.Errorf("unable to find %s in the jpi store", p.Identifier())
+		}
+		names := []string{path.Join(bundledPluginsDir, fmt.Sprintf("%s.jpi", p.Name))}
+		if detached := path.Join(detachedPluginsDir, fmt.Sprintf("%s.hpi", p.Name)); entries[detached] != nil {
+			log.Printf("replacing Jenkins detached plugin with %s\n", p.Identifier())
+			names = append(names, detached)
+		}
+		for _, name := range names {
+			entries[name] = &bundleEntry{name: name, file: src}
+			if err := updateManifestDigest(manifest, name, src); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	entries[manifestPath] = &bundleEntry{name: manifestPath}
+
+	t, err := renameio.TempFile("", output)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer t.Cleanup()
+
+	if err := writeBundle(t, sortedEntries(entries), manifest); err != nil {
+		return errors.Trace(err)
+	}
+
+	return t.CloseAtomicallyReplace()
+}
+
+// sortedEntries returns the entries sorted by name, but the manifest ones.
+// By convention, the manifest must be the first entry of a jar file.
+func sortedEntries(entries map[string]*bundleEntry) []*bundleEntry {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := manifestRank(names[i]), manifestRank(names[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	sorted := make([]*bundleEntry, 0, len(names))
+	for _, name := range names {
+		sorted = append(sorted, entries[name])
+	}
+	return sorted
+}
+
+func manifestRank(name string) int {
+	switch name {
+	case path.Dir(manifestPath) + "/":
+		return 0
+	case manifestPath:
+		return 1
+	}
+	return 2
+}
+
+func writeBundle(w io.Writer, entries []*bundleEntry, manifest *jar.Manifest) error {
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		if err := writeBundleEntry(zw, e, manifest); err != nil {
+			return errors.Annotatef(err, "unable to write %s", e.name)
+		}
+	}
+	return errors.Trace(zw.Close())
+}
+
+func writeBundleEntry(zw *zip.Writer, e *bundleEntry, manifest *jar.Manifest) error {
+	// Entries copied from the input war keep their compressed data untouched
+	if e.zf != nil {
+		fh := e.zf.FileHeader
+		fh.Modified = bundleModTime
+		fh.Extra = nil
+		// CreateRaw does not populate the MS-DOS date and time fields
+		fh.ModifiedDate = uint16((bundleModTime.Year()-1980)<<9 | int(bundleModTime.Month())<<5 | bundleModTime.Day())
+		fh.ModifiedTime = uint16(bundleModTime.Hour()<<11 | bundleModTime.Minute()<<5 | bundleModTime.Second()>>1)
+		w, err := zw.CreateRaw(&fh)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		r, err := e.zf.OpenRaw()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		_, err = io.Copy(w, r)
+		return errors.Trace(err)
+	}
+
+	fh := &zip.FileHeader{
+		Name:     e.name,
+		Method:   zip.Deflate,
+		Modified: bundleModTime,
+	}
+	if strings.HasSuffix(e.name, "/") {
+		fh.Method = zip.Store
+	}
+	w, err := zw.CreateHeader(fh)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	switch {
+	case e.name == manifestPath:
+		_, err = io.WriteString(w, manifest.String())
+	case e.file != "":
+		err = copyFile(w, e.file)
+	}
+	return errors.Trace(err)
+}
+
+func copyFile(w io.Writer, filename string) error {
+	r, err := os.Open(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer r.Close()
+
+	_, err = io.Copy(w, r)
+	return errors.Trace(err)
+}
+
+func readEntry(e *bundleEntry) ([]byte, error) {
+	if e == nil || e.zf == nil {
+		return nil, errors.NotFoundf("entry")
+	}
+	rc, err := e.zf.Open()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+// updateManifestDigest keeps the manifest digests consistent with the bundle
+// content. Only manifests listing digests (signed wars) get updated.
+func updateManifestDigest(manifest *jar.Manifest, name string, filename string) error {
+	digests := false
+	for _, s := range manifest.Sections {
+		if s.Get(digestAttribute) != "" {
+			digests = true
+			break
+		}
+	}
+	if !digests {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sum := sha256.Sum256(data)
+	digest := base64.StdEncoding.EncodeToString(sum[:])
+
+	if s := manifest.Section(name); s != nil {
+		s.Set(digestAttribute, digest)
+		return nil
+	}
+	manifest.Sections = append(manifest.Sections, jar.Section{
+		{Key: "Name", Value: name},
+		{Key: digestAttribute, Value: digest},
+	})
+	return nil
+}
+
+func isSignatureFile(name string) bool {
+	if path.Dir(name) != path.Dir(manifestPath) {
+		return false
+	}
+	switch path.Ext(name) {
+	case ".SF", ".RSA", ".DSA", ".EC":
+		return true
+	}
+	return false
+}
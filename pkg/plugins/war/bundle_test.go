@@ -0,0 +1,134 @@
+package war
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/common"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jar"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jpi"
+	zipper "github.com/bitnami-labs/jenkins-plugins-resolver/pkg/zip"
+)
+
+// writeTestPlugin writes a minimal jpi file for the given plugin in the jpi store
+func writeTestPlugin(p *api.Plugin, workingDir string) error {
+	f, err := os.Create(jpi.GetPluginPath(p, workingDir))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	w, err := zw.Create(manifestPath)
+	if err != nil {
+		return err
+	}
+	manifest := fmt.Sprintf("Plugin-Version: %s\r\nShort-Name: %s\r\nLong-Name: %s\r\n", p.Version, p.Name, p.Name)
+	if _, err := w.Write([]byte(manifest)); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func TestBundle(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "jpr")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(workingDir)
+	if err := common.EnsureStorePathExists(workingDir, jpi.GetStorePath); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	lock := &api.PluginsRegistry{
+		Plugins: []*api.Plugin{
+			// ldap is a detached plugin in the test war
+			{Name: "ldap", Version: "99.0"},
+			{Name: "foo", Version: "1.0"},
+		},
+	}
+	for _, p := range lock.Plugins {
+		if err := writeTestPlugin(p, workingDir); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+
+	var outputs [][]byte
+	for i := 0; i < 2; i++ {
+		output := filepath.Join(workingDir, fmt.Sprintf("jenkins-%d.war", i))
+		if err := Bundle("testdata/jenkins.foo.war", lock, workingDir, output); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		data, err := ioutil.ReadFile(output)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		outputs = append(outputs, data)
+	}
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Errorf("bundling the same inputs twice generated different wars")
+	}
+
+	output := filepath.Join(workingDir, "jenkins-0.war")
+	for _, tc := range []struct {
+		file string
+		want string
+	}{
+		{"WEB-INF/plugins/foo.jpi", "1.0"},
+		{"WEB-INF/plugins/ldap.jpi", "99.0"},
+		{"WEB-INF/detached-plugins/ldap.hpi", "99.0"},
+	} {
+		data, err := zipper.ExtractFile(output, tc.file)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		pm, err := readTestPluginManifest(data)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if got := pm.Main.Get("Plugin-Version"); got != tc.want {
+			t.Errorf("%s: wanted version %s, got: %s", tc.file, tc.want, got)
+		}
+	}
+
+	// The manifest must be the first entry and the rest must be sorted
+	zr, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer zr.Close()
+	if zr.File[0].Name != "META-INF/" || zr.File[1].Name != manifestPath {
+		t.Errorf("wanted the manifest first, got: %s, %s", zr.File[0].Name, zr.File[1].Name)
+	}
+	for i := 3; i < len(zr.File); i++ {
+		if zr.File[i-1].Name > zr.File[i].Name {
+			t.Errorf("entries are not sorted: %s > %s", zr.File[i-1].Name, zr.File[i].Name)
+		}
+		if !zr.File[i].Modified.Equal(bundleModTime) {
+			t.Errorf("%s: wanted modification time %s, got: %s", zr.File[i].Name, bundleModTime, zr.File[i].Modified)
+		}
+	}
+}
+
+func readTestPluginManifest(data []byte) (*jar.Manifest, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	manifest, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return jar.ParseManifest(string(manifest))
+}
@@ -0,0 +1,112 @@
+package war
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/crypto"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/common"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/httpdownloader"
+	"github.com/juju/errors"
+)
+
+// ReadCore reads a Jenkins struct for the core declared in a project file:
+//
+// - If the core declares a war file, it will be read from disk. Relative paths are relative to baseDir.
+// - If the core declares a URL, the war will be downloaded from it.
+// - Otherwise, the war will be downloaded with the provided downloader.
+//
+// The war is verified against the declared checksum (if any), even if it is in the store already, and it
+// must match the declared version.
+func ReadCore(core *api.Core, d common.WarDownloader, baseDir string, workingDir string) (*api.Jenkins, error) {
+	if core.Version == "" {
+		return nil, errors.Errorf("the jenkins core version is required")
+	}
+
+	var jk *api.Jenkins
+	var err error
+	switch {
+	case core.War != "":
+		warfile := core.War
+		if !filepath.IsAbs(warfile) {
+			warfile = filepath.Join(baseDir, warfile)
+		}
+		if core.Checksum != "" {
+			got, err := crypto.SHA256File(warfile)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if got != strings.ToLower(core.Checksum) {
+				return nil, errors.Errorf("checksum mismatch for %q: wanted %s, got %s", warfile, core.Checksum, got)
+			}
+		}
+		jk, err = Read(warfile, workingDir)
+	default:
+		if core.Url != "" {
+			d = &urlDownloader{url: core.Url}
+		}
+		if core.Checksum == "" {
+			jk, err = ReadVersion(core.Version, d, workingDir)
+			break
+		}
+		jk, err = readVerifiedVersion(core.Version, core.Checksum, d, workingDir)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if jk.Version != core.Version {
+		return nil, errors.Errorf("the jenkins war is not the declared one: wanted jenkins:%s, got jenkins:%s", core.Version, jk.Version)
+	}
+	return jk, nil
+}
+
+// readVerifiedVersion reads a Jenkins struct for the requested Jenkins version,
+// verifying the war against the declared checksum. Unlike ReadVersion, the war
+// is verified even if it was already in the store.
+func readVerifiedVersion(version string, checksum string, d common.WarDownloader, workingDir string) (*api.Jenkins, error) {
+	warfile, err := Fetch(version, &checksumDownloader{WarDownloader: d, checksum: checksum}, workingDir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	got, err := crypto.SHA256File(warfile)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if got != strings.ToLower(checksum) {
+		return nil, errors.Errorf("checksum mismatch for the stored jenkins:%s war %q: wanted %s, got %s", version, warfile, checksum, got)
+	}
+	return Read(warfile, workingDir)
+}
+
+// checksumDownloader verifies the downloaded wars against a declared checksum
+// instead of the published one
+type checksumDownloader struct {
+	common.WarDownloader
+	checksum string
+}
+
+func (d *checksumDownloader) GetWarChecksum(context.Context, string) (string, error) {
+	return strings.ToLower(d.checksum), nil
+}
+
+// urlDownloader downloads a war from a fixed URL, looking for the checksum
+// published next to the war
+type urlDownloader struct {
+	url string
+}
+
+func (d *urlDownloader) GetWarDownloadURL(string) string {
+	return d.url
+}
+
+func (d *urlDownloader) DownloadWar(ctx context.Context, _ string, w io.Writer) error {
+	return httpdownloader.Download(ctx, d.url, w)
+}
+
+func (d *urlDownloader) GetWarChecksum(ctx context.Context, _ string) (string, error) {
+	return httpdownloader.DownloadChecksum(ctx, d.url+".sha256")
+}
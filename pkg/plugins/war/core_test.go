@@ -0,0 +1,108 @@
+package war
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/common"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/jenkinsdownloader"
+)
+
+func TestReadCore(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/jenkins.foo.war")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	checksum := fmt.Sprintf("%x", sha256.Sum256(data))
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	mirror := newTestMirror(t, checksum)
+	defer mirror.Close()
+
+	testCases := []struct {
+		name    string
+		core    *api.Core
+		success bool
+	}{
+		{"war", &api.Core{Version: "2.176.3", War: "jenkins.foo.war"}, true},
+		{"war with checksum", &api.Core{Version: "2.176.3", War: "jenkins.foo.war", Checksum: checksum}, true},
+		{"war with absolute path", &api.Core{Version: "2.176.3", War: filepath.Join(cwd, "testdata/jenkins.foo.war")}, true},
+		{"war with wrong checksum", &api.Core{Version: "2.176.3", War: "jenkins.foo.war", Checksum: "foo"}, false},
+		{"war with wrong version", &api.Core{Version: "2.176.4", War: "jenkins.foo.war"}, false},
+		{"url", &api.Core{Version: "2.176.3", Url: mirror.URL + "/war-stable/2.176.3/jenkins.war"}, true},
+		{"url with wrong checksum", &api.Core{Version: "2.176.3", Url: mirror.URL + "/war-stable/2.176.3/jenkins.war", Checksum: "foo"}, false},
+		{"version", &api.Core{Version: "2.176.3"}, true},
+		{"version with checksum", &api.Core{Version: "2.176.3", Checksum: checksum}, true},
+		{"version with wrong checksum", &api.Core{Version: "2.176.3", Checksum: "foo"}, false},
+		{"missing version", &api.Core{War: "jenkins.foo.war"}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			workingDir, err := ioutil.TempDir("", "jpr")
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			defer os.RemoveAll(workingDir)
+			for _, fn := range []func(string) string{GetStorePath, GetArtifactStorePath} {
+				if err := common.EnsureStorePathExists(workingDir, fn); err != nil {
+					t.Fatalf("%+v", err)
+				}
+			}
+
+			d := jenkinsdownloader.NewDownloader()
+			d.WarURL = mirror.URL
+			jk, err := ReadCore(tc.core, d, "testdata", workingDir)
+			if !tc.success {
+				if err == nil {
+					t.Errorf("not expected to read %s but it could", tc.core)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if jk.Version != tc.core.Version {
+				t.Errorf("wanted: %s, got: %s", tc.core.Version, jk.Version)
+			}
+		})
+	}
+}
+
+func TestReadCoreStoredChecksum(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/jenkins.foo.war")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	mirror := newTestMirror(t, fmt.Sprintf("%x", sha256.Sum256(data)))
+	defer mirror.Close()
+
+	workingDir, err := ioutil.TempDir("", "jpr")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(workingDir)
+	for _, fn := range []func(string) string{GetStorePath, GetArtifactStorePath} {
+		if err := common.EnsureStorePathExists(workingDir, fn); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	d := jenkinsdownloader.NewDownloader()
+	d.WarURL = mirror.URL
+
+	// The war is stored without declaring any checksum
+	if _, err := ReadCore(&api.Core{Version: "2.176.3"}, d, "testdata", workingDir); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	// A checksum declared later is verified against the stored war
+	if _, err := ReadCore(&api.Core{Version: "2.176.3", Checksum: "foo"}, d, "testdata", workingDir); err == nil {
+		t.Errorf("not expected to read the stored war with a wrong checksum but it could")
+	}
+}
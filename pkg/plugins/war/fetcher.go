@@ -0,0 +1,85 @@
+package war
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/common"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/google/renameio"
+	"github.com/juju/errors"
+)
+
+const (
+	timeoutMin = 10
+)
+
+// Fetch will download the war for the requested Jenkins version to the store and it
+// will verify it against the published checksum. It returns the path to the war.
+func Fetch(version string, d common.WarDownloader, workingDir string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutMin*time.Minute)
+	defer cancel()
+
+	warPath := GetArtifactPath(version, workingDir)
+	if cached, err := utils.FileExists(warPath); err != nil {
+		return "", errors.Trace(err)
+	} else if cached {
+		return warPath, nil
+	}
+
+	checksum, err := d.GetWarChecksum(ctx, version)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+
+	log.Printf("> downloading jenkins:%s war...\n", version)
+
+	t, err := renameio.TempFile("", warPath)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer t.Cleanup()
+
+	h := sha256.New()
+	if err := d.DownloadWar(ctx, version, io.MultiWriter(t, h)); err != nil {
+		return "", errors.Annotatef(err, "unable to download %q", d.GetWarDownloadURL(version))
+	}
+	if got := fmt.Sprintf("%x", h.Sum(nil)); got != checksum {
+		return "", errors.Errorf("checksum mismatch for %q: wanted %s, got %s", d.GetWarDownloadURL(version), checksum, got)
+	}
+
+	return warPath, t.CloseAtomicallyReplace()
+}
+
+// ReadVersion reads a Jenkins struct for the requested Jenkins version.
+// If it does not exist in the store, it will download the war and parse it.
+func ReadVersion(version string, d common.WarDownloader, workingDir string) (*api.Jenkins, error) {
+	jenkinsPath := GetWarPath(&JenkinsManifest{Version: version}, workingDir)
+	cached, err := utils.FileExists(jenkinsPath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if cached {
+		return ReadWar(jenkinsPath)
+	}
+
+	warfile, err := Fetch(version, d, workingDir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	jk, err := Read(warfile, workingDir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if jk.Version != version {
+		return nil, errors.Errorf("the downloaded war is not the requested one: wanted jenkins:%s, got jenkins:%s", version, jk.Version)
+	}
+	return jk, nil
+}
@@ -0,0 +1,89 @@
+package war
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/common"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/jenkinsdownloader"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+)
+
+// newTestMirror serves the test war as the 2.176.3 LTS version with the given checksum
+func newTestMirror(t *testing.T, checksum string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/war-stable/2.176.3/jenkins.war", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/jenkins.foo.war")
+	})
+	mux.HandleFunc("/war-stable/2.176.3/jenkins.war.sha256", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  jenkins.war\n", checksum)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestReadVersion(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/jenkins.foo.war")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	testCases := []struct {
+		version  string
+		checksum string
+		success  bool
+	}{
+		{"2.176.3", fmt.Sprintf("%x", sha256.Sum256(data)), true},
+		{"2.176.3", fmt.Sprintf("%x", sha256.Sum256([]byte("foo"))), false},
+		{"2.176.4", fmt.Sprintf("%x", sha256.Sum256(data)), false},
+	}
+	for _, tc := range testCases {
+		workingDir, err := ioutil.TempDir("", "jpr")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		defer os.RemoveAll(workingDir)
+		for _, fn := range []func(string) string{GetStorePath, GetArtifactStorePath} {
+			if err := common.EnsureStorePathExists(workingDir, fn); err != nil {
+				t.Fatalf("%+v", err)
+			}
+		}
+
+		mirror := newTestMirror(t, tc.checksum)
+		defer mirror.Close()
+		d := jenkinsdownloader.NewDownloader()
+		d.WarURL = mirror.URL
+
+		jk, err := ReadVersion(tc.version, d, workingDir)
+		if !tc.success {
+			if err == nil {
+				t.Errorf("not expected to read jenkins:%s (checksum %s) but it could", tc.version, tc.checksum)
+			}
+			if ok, err := utils.FileExists(GetArtifactPath(tc.version, workingDir)); err != nil {
+				t.Fatalf("%+v", err)
+			} else if ok {
+				t.Errorf("not expected to store the jenkins:%s war but it did", tc.version)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if jk.Version != tc.version {
+			t.Errorf("wanted: %s, got: %s", tc.version, jk.Version)
+		}
+
+		// Both, the war and the parsed data, are cached so the mirror is not needed anymore
+		mirror.Close()
+		if _, err := ReadVersion(tc.version, d, workingDir); err != nil {
+			t.Errorf("expected to read jenkins:%s from the store but it could not: %+v", tc.version, err)
+		}
+		if _, err := Fetch(tc.version, d, workingDir); err != nil {
+			t.Errorf("expected to fetch jenkins:%s from the store but it could not: %+v", tc.version, err)
+		}
+	}
+}
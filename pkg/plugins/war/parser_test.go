@@ -42,21 +42,24 @@ func TestParse(t *testing.T) {
      "name": "script-security",
      "version": "1.18.1"
     }
-   ]
+   ],
+   "requiredCore": "2.85"
   },
   {
    "fullName": "Jenkins CVS Plug-in",
    "plugin": {
     "name": "cvs",
     "version": "2.11"
-   }
+   },
+   "requiredCore": "1.447"
   },
   {
    "fullName": "JUnit Plugin",
    "plugin": {
     "name": "junit",
     "version": "1.6"
-   }
+   },
+   "requiredCore": "1.580.1"
   }
  ]
 }`,
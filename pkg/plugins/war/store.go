@@ -14,3 +14,13 @@ func GetStorePath(workingDir string) string {
 func GetWarPath(jm *JenkinsManifest, workingDir string) string {
 	return filepath.Join(GetStorePath(workingDir), fmt.Sprintf("jenkins-%s.war", jm.Version))
 }
+
+// GetArtifactStorePath returns the path to the store of war artifacts
+func GetArtifactStorePath(workingDir string) string {
+	return filepath.Join(GetStorePath(workingDir), "artifacts")
+}
+
+// GetArtifactPath returns the path to the war artifact in the store
+func GetArtifactPath(version string, workingDir string) string {
+	return filepath.Join(GetArtifactStorePath(workingDir), fmt.Sprintf("jenkins-%s.war", version))
+}
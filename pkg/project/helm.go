@@ -0,0 +1,173 @@
+package project
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/ghodss/yaml"
+	"github.com/google/renameio"
+	"github.com/juju/errors"
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+// HelmKeys are the key paths of the plugins lists in the Jenkins Helm chart values
+var HelmKeys = []string{
+	"controller.installPlugins",
+	"controller.additionalPlugins",
+}
+
+// ReadHelmValues reads the plugins lists of a Helm chart values file. Every key is a
+// dot-separated path to a list of plugins in the plugins.txt format (e.g.
+// "kubernetes:4029.v5712230ccb_f8"). Missing keys are ignored, but at least one of
+// them must be present.
+func ReadHelmValues(filename string, keys []string, r VersionResolver) (*api.Project, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	p, err := readHelmValues(data, keys, r)
+	return p, errors.Annotatef(err, "unable to read %s", filename)
+}
+
+func readHelmValues(data []byte, keys []string, r VersionResolver) (*api.Project, error) {
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	deps := newDependencies(r)
+	found := false
+	for _, key := range keys {
+		value, ok := lookupHelmKey(values, key)
+		if !ok {
+			continue
+		}
+		found = true
+		// The chart disables the plugins installation with "installPlugins: false"
+		if value == nil || value == false {
+			continue
+		}
+		plugins, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("%s is not a list of plugins", key)
+		}
+		for _, p := range plugins {
+			s, ok := p.(string)
+			if !ok {
+				return nil, errors.Errorf("%s: malformed plugin %v", key, p)
+			}
+			src, err := parsePlugin(s)
+			if err != nil {
+				return nil, errors.Annotate(err, key)
+			}
+			if err := deps.add(src); err != nil {
+				return nil, errors.Annotate(err, key)
+			}
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("unable to find any of the %s keys", strings.Join(keys, ", "))
+	}
+	return &api.Project{Dependencies: deps.versions}, nil
+}
+
+// lookupHelmKey returns the value of a dot-separated key path
+func lookupHelmKey(values map[string]interface{}, key string) (interface{}, bool) {
+	var value interface{} = values
+	for _, k := range strings.Split(key, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// WriteHelmValues writes the locked plugins to a Helm chart values file at the given
+// dot-separated key path, as a list of plugins in the plugins.txt format. The lock
+// includes every plugin, so the lists at the other keys are emptied (even in values
+// files merged after the ones that declare them). Otherwise, the plugins would be
+// installed twice and the values could not be read again. The rest of the values are kept (in the same order), but comments are not
+// preserved. The file is created if it does not exist.
+func WriteHelmValues(filename string, key string, others []string, lock *api.PluginsRegistry) error {
+	mode := os.FileMode(0644)
+	data, err := ioutil.ReadFile(filename)
+	if err == nil {
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		mode = fi.Mode()
+	} else if !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+
+	data, err = writeHelmValues(data, key, others, lock)
+	if err != nil {
+		return errors.Annotatef(err, "unable to write %s", filename)
+	}
+	return errors.Trace(renameio.WriteFile(filename, data, mode))
+}
+
+func writeHelmValues(data []byte, key string, others []string, lock *api.PluginsRegistry) ([]byte, error) {
+	var values yamlv2.MapSlice
+	if err := yamlv2.Unmarshal(data, &values); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	plugins := make([]string, 0, len(lock.GetPlugins()))
+	for _, p := range lock.GetPlugins() {
+		plugins = append(plugins, fmt.Sprintf("%s:%s", p.GetName(), p.GetVersion()))
+	}
+	values, err := setHelmKey(values, strings.Split(key, "."), plugins)
+	if err != nil {
+		return nil, errors.Annotate(err, key)
+	}
+	for _, other := range others {
+		if other == key {
+			continue
+		}
+		if values, err = setHelmKey(values, strings.Split(other, "."), []string{}); err != nil {
+			return nil, errors.Annotate(err, other)
+		}
+	}
+	return yamlv2.Marshal(values)
+}
+
+// setHelmKey sets the value of a key path, creating the missing intermediate maps
+func setHelmKey(values yamlv2.MapSlice, path []string, value interface{}) (yamlv2.MapSlice, error) {
+	for i, item := range values {
+		if item.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			values[i].Value = value
+			return values, nil
+		}
+		m, ok := item.Value.(yamlv2.MapSlice)
+		if !ok && item.Value != nil {
+			return nil, errors.Errorf("%s is not a map", path[0])
+		}
+		m, err := setHelmKey(m, path[1:], value)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		values[i].Value = m
+		return values, nil
+	}
+
+	if len(path) > 1 {
+		m, err := setHelmKey(nil, path[1:], value)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		value = m
+	}
+	return append(values, yamlv2.MapItem{Key: path[0], Value: value}), nil
+}
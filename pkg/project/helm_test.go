@@ -0,0 +1,162 @@
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestReadHelmValues(t *testing.T) {
+	testCases := []struct {
+		keys []string
+		want *api.Project
+	}{
+		{HelmKeys, &api.Project{
+			Dependencies: map[string]string{
+				"kubernetes": "4029.v5712230ccb_f8",
+				"git":        "5.2.1",
+				"mailer":     "latest-resolved",
+			},
+		}},
+		{[]string{"controller.installPlugins", "agent.installPlugins"}, &api.Project{
+			Dependencies: map[string]string{
+				"kubernetes": "4029.v5712230ccb_f8",
+				"git":        "5.2.1",
+			},
+		}},
+	}
+	for _, tc := range testCases {
+		got, err := ReadHelmValues("testdata/values.yaml", tc.keys, fakeResolver{})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !proto.Equal(got, tc.want) {
+			t.Errorf("%v: wanted: %s, got: %s", tc.keys, tc.want, got)
+		}
+	}
+}
+
+func TestReadHelmValuesMalformed(t *testing.T) {
+	testCases := []struct {
+		name   string
+		values string
+	}{
+		{"missing keys", "controller:\n  image: jenkins/jenkins\n"},
+		{"not a list", "controller:\n  installPlugins: git:5.2.1\n"},
+		{"not a string", "controller:\n  installPlugins:\n    - name: git\n"},
+		{"duplicated plugin", "controller:\n  installPlugins: [git:5.2.1]\n  additionalPlugins: [git:5.2.0]\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := readHelmValues([]byte(tc.values), HelmKeys, fakeResolver{}); err == nil {
+				t.Errorf("not expected to read %q but it could", tc.values)
+			}
+		})
+	}
+}
+
+func TestWriteHelmValues(t *testing.T) {
+	lockJSON := `{"plugins": [{"name": "git", "version": "5.2.1"}, {"name": "mailer", "version": "472.vf7c289a_4b_420"}]}`
+	lock := &api.PluginsRegistry{}
+	if err := jsonpb.UnmarshalString(lockJSON, lock); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		key    string
+		others []string
+		values string
+		want   string
+	}{
+		{
+			name:   "replace list",
+			key:    "controller.installPlugins",
+			values: "controller:\n  image: jenkins/jenkins\n  installPlugins:\n  - git:5.0.0\n  tag: lts\npersistence:\n  enabled: true\n",
+			want:   "controller:\n  image: jenkins/jenkins\n  installPlugins:\n  - git:5.2.1\n  - mailer:472.vf7c289a_4b_420\n  tag: lts\npersistence:\n  enabled: true\n",
+		},
+		{
+			name:   "missing key",
+			key:    "controller.installPlugins",
+			others: HelmKeys,
+			values: "persistence:\n  enabled: true\n",
+			want:   "persistence:\n  enabled: true\ncontroller:\n  installPlugins:\n  - git:5.2.1\n  - mailer:472.vf7c289a_4b_420\n  additionalPlugins: []\n",
+		},
+		{
+			name:   "other lists",
+			key:    "controller.additionalPlugins",
+			others: HelmKeys,
+			values: "controller:\n  installPlugins:\n  - git:5.0.0\n  additionalPlugins:\n  - mailer\n",
+			want:   "controller:\n  installPlugins: []\n  additionalPlugins:\n  - git:5.2.1\n  - mailer:472.vf7c289a_4b_420\n",
+		},
+		{
+			name:   "empty file",
+			key:    "installPlugins",
+			values: "",
+			want:   "installPlugins:\n- git:5.2.1\n- mailer:472.vf7c289a_4b_420\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := writeHelmValues([]byte(tc.values), tc.key, tc.others, lock)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("wanted:\n%s\ngot:\n%s", tc.want, got)
+			}
+		})
+	}
+
+	if _, err := writeHelmValues([]byte("controller: jenkins\n"), "controller.installPlugins", HelmKeys, lock); err == nil {
+		t.Errorf("not expected to write into a non-map key but it could")
+	}
+}
+
+func TestWriteReadHelmValues(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	data, err := ioutil.ReadFile("testdata/values.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(tmpDir, "values.yaml")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The values file has both keys, and the lock includes the plugins of both of them
+	project, err := ReadHelmValues(filename, HelmKeys, fakeResolver{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	lock := &api.PluginsRegistry{}
+	for name, version := range project.Dependencies {
+		lock.Plugins = append(lock.Plugins, &api.Plugin{Name: name, Version: version})
+	}
+	lock.Plugins = append(lock.Plugins, &api.Plugin{Name: "structs", Version: "1.7"})
+	if err := WriteHelmValues(filename, HelmKeys[0], HelmKeys, lock); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	got, err := ReadHelmValues(filename, HelmKeys, fakeResolver{})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	want := &api.Project{Dependencies: map[string]string{"structs": "1.7"}}
+	for name, version := range project.Dependencies {
+		want.Dependencies[name] = version
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("wanted: %s, got: %s", want, got)
+	}
+}
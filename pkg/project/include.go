@@ -0,0 +1,233 @@
+package project
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/mkmik/multierror"
+)
+
+// includer reads project files and their includes
+type includer struct {
+	opts *Options
+	// stack is the chain of files being read, to detect include cycles
+	stack []string
+}
+
+// included is a project merged with its includes. It keeps track of the
+// file every dependency (and the jenkins core, profiles and overrides) comes from.
+type included struct {
+	project         *api.Project
+	origins         map[string]string
+	coreOrigin      string
+	profileOrigins  map[string]string
+	overrideOrigins map[string]string
+}
+
+func newIncluded() *included {
+	return &included{
+		project:         &api.Project{Dependencies: map[string]string{}},
+		origins:         map[string]string{},
+		profileOrigins:  map[string]string{},
+		overrideOrigins: map[string]string{},
+	}
+}
+
+// read reads a project file and merges it with its includes. The included
+// files are merged first, and then the including file overrides them.
+func (in *includer) read(filename string) (*included, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, f := range in.stack {
+		if f == abs {
+			return nil, errors.Errorf("include cycle: %s", strings.Join(append(in.stack, abs), " > "))
+		}
+	}
+	in.stack = append(in.stack, abs)
+	defer func() { in.stack = in.stack[:len(in.stack)-1] }()
+
+	p, err := readFile(filename, in.opts)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	res := newIncluded()
+	for _, inc := range p.GetInclude() {
+		path := inc
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(filename), inc)
+		}
+		ip, err := in.read(path)
+		if err != nil {
+			return nil, errors.Annotatef(err, "unable to include %s in %s", inc, filename)
+		}
+		// The war path is relative to the included file
+		if war := ip.project.GetJenkins().GetWar(); war != "" && !filepath.IsAbs(war) {
+			ip.project.Jenkins.War = filepath.Join(filepath.Dir(inc), war)
+		}
+		if err := res.merge(ip, filename, p); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	// The including file wins
+	for name, version := range p.GetDependencies() {
+		res.project.Dependencies[name] = version
+		res.origins[name] = filename
+	}
+	if p.GetJenkins() != nil {
+		res.project.Jenkins = p.GetJenkins()
+		res.coreOrigin = filename
+	}
+	for name, profile := range p.GetProfiles() {
+		if res.project.Profiles == nil {
+			res.project.Profiles = map[string]*api.Profile{}
+		}
+		res.project.Profiles[name] = profile
+		res.profileOrigins[name] = filename
+	}
+	for name, version := range p.GetOverrides() {
+		if res.project.Overrides == nil {
+			res.project.Overrides = map[string]string{}
+		}
+		res.project.Overrides[name] = version
+		res.overrideOrigins[name] = filename
+	}
+	res.addExcludes(p.GetExcludes())
+	return res, nil
+}
+
+// merge merges an included project. Conflicting versions between the files
+// included by the same file are an error, unless the including file declares
+// the plugin (or the jenkins core or profile) itself, as it overrides both of them.
+func (res *included) merge(in *included, includer string, overrides *api.Project) error {
+	var errs error
+	for name, version := range in.project.GetDependencies() {
+		_, overridden := overrides.GetDependencies()[name]
+		if v, ok := res.project.Dependencies[name]; ok && v != version && !overridden {
+			errs = multierror.Append(errs, errors.Errorf("conflicting versions of the %s plugin: %s (%s) and %s (%s). Please declare the %s plugin in %s to override them", name, v, res.origins[name], version, in.origins[name], name, includer))
+			continue
+		}
+		res.project.Dependencies[name] = version
+		res.origins[name] = in.origins[name]
+	}
+
+	if jk := in.project.GetJenkins(); jk != nil {
+		if res.project.Jenkins != nil && !proto.Equal(res.project.Jenkins, jk) && overrides.GetJenkins() == nil {
+			errs = multierror.Append(errs, errors.Errorf("conflicting jenkins cores: %s (%s) and %s (%s). Please declare the jenkins core in %s to override them", res.project.Jenkins.GetVersion(), res.coreOrigin, jk.GetVersion(), in.coreOrigin, includer))
+		} else {
+			res.project.Jenkins = jk
+			res.coreOrigin = in.coreOrigin
+		}
+	}
+
+	for name, profile := range in.project.GetProfiles() {
+		_, overridden := overrides.GetProfiles()[name]
+		if pr, ok := res.project.Profiles[name]; ok && !proto.Equal(pr, profile) && !overridden {
+			errs = multierror.Append(errs, errors.Errorf("conflicting %s profiles: %s and %s. Please declare the %s profile in %s to override them", name, res.profileOrigins[name], in.profileOrigins[name], name, includer))
+			continue
+		}
+		if res.project.Profiles == nil {
+			res.project.Profiles = map[string]*api.Profile{}
+		}
+		res.project.Profiles[name] = profile
+		res.profileOrigins[name] = in.profileOrigins[name]
+	}
+
+	for name, version := range in.project.GetOverrides() {
+		_, overridden := overrides.GetOverrides()[name]
+		if v, ok := res.project.Overrides[name]; ok && v != version && !overridden {
+			errs = multierror.Append(errs, errors.Errorf("conflicting overrides of the %s plugin: %s (%s) and %s (%s). Please override the %s plugin in %s", name, v, res.overrideOrigins[name], version, in.overrideOrigins[name], name, includer))
+			continue
+		}
+		if res.project.Overrides == nil {
+			res.project.Overrides = map[string]string{}
+		}
+		res.project.Overrides[name] = version
+		res.overrideOrigins[name] = in.overrideOrigins[name]
+	}
+	res.addExcludes(in.project.GetExcludes())
+	return errs
+}
+
+// addExcludes adds the excluded plugins that are not excluded yet
+func (res *included) addExcludes(excludes []string) {
+	for _, e := range excludes {
+		found := false
+		for _, re := range res.project.Excludes {
+			if re == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res.project.Excludes = append(res.project.Excludes, e)
+		}
+	}
+}
+
+// Includes returns the files included by a project file and its includes, in the
+// order they are read. Unlike Read, the plugin versions are not resolved and the
+// included files are not merged. Included files that do not exist are returned,
+// but they are not read.
+func Includes(filename string, opts *Options) ([]string, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	var res []string
+	if err := listIncludes(filename, opts, map[string]bool{}, &res); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return res, nil
+}
+
+func listIncludes(filename string, opts *Options, seen map[string]bool, res *[]string) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if seen[abs] {
+		return nil
+	}
+	seen[abs] = true
+
+	// plugins.txt and plugins.yaml files cannot include other files
+	if filepath.Ext(filename) == ".txt" {
+		return nil
+	}
+	ok, err := utils.FileExists(filename)
+	if err != nil || !ok {
+		return errors.Trace(err)
+	}
+	if ext := filepath.Ext(filename); ext == ".yaml" || ext == ".yml" {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if isPIMFile(data) {
+			return nil
+		}
+	}
+	p, err := readFile(filename, opts)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, inc := range p.GetInclude() {
+		path := inc
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(filename), inc)
+		}
+		*res = append(*res, path)
+		if err := listIncludes(path, opts, seen, res); err != nil {
+			return errors.Annotatef(err, "unable to include %s in %s", inc, filename)
+		}
+	}
+	return nil
+}
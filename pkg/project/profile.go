@@ -0,0 +1,49 @@
+package project
+
+import (
+	"sort"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+)
+
+// ApplyProfile returns the project for the given profile: the project
+// dependencies without the removed ones, plus the profile dependencies
+// (which override the project ones). The returned project does not include
+// any profile, so an empty profile name returns the base project.
+func ApplyProfile(p *api.Project, name string) (*api.Project, error) {
+	res := proto.Clone(p).(*api.Project)
+	res.Profiles = nil
+	if name == "" {
+		return res, nil
+	}
+
+	profile, ok := p.GetProfiles()[name]
+	if !ok {
+		return nil, errors.Errorf("unknown profile %q. Available profiles: %v", name, ProfileNames(p))
+	}
+	if res.Dependencies == nil {
+		res.Dependencies = map[string]string{}
+	}
+	for _, dep := range profile.GetRemove() {
+		if _, ok := res.Dependencies[dep]; !ok {
+			return nil, errors.Errorf("profile %q removes the %s plugin, but the project does not depend on it", name, dep)
+		}
+		delete(res.Dependencies, dep)
+	}
+	for dep, version := range profile.GetDependencies() {
+		res.Dependencies[dep] = version
+	}
+	return res, nil
+}
+
+// ProfileNames returns the sorted names of the project profiles
+func ProfileNames(p *api.Project) []string {
+	names := make([]string, 0, len(p.GetProfiles()))
+	for name := range p.GetProfiles() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
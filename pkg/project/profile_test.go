@@ -0,0 +1,66 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestApplyProfile(t *testing.T) {
+	projectJSON := `{
+		"dependencies": {"git": "5.2.1", "kubernetes": "4029.v5712230ccb_f8", "mailer": "1.6"},
+		"jenkins": {"version": "2.426.3"},
+		"profiles": {
+			"dev": {"dependencies": {"pipeline-graph-view": "216.vf_1ee8d8d2c44"}, "remove": ["mailer"]},
+			"staging": {"dependencies": {"kubernetes": "4174.v4230d0ccd951"}}
+		}
+	}`
+	testCases := []struct {
+		profile string
+		want    string
+	}{
+		{"", `{
+			"dependencies": {"git": "5.2.1", "kubernetes": "4029.v5712230ccb_f8", "mailer": "1.6"},
+			"jenkins": {"version": "2.426.3"}
+		}`},
+		{"dev", `{
+			"dependencies": {"git": "5.2.1", "kubernetes": "4029.v5712230ccb_f8", "pipeline-graph-view": "216.vf_1ee8d8d2c44"},
+			"jenkins": {"version": "2.426.3"}
+		}`},
+		{"staging", `{
+			"dependencies": {"git": "5.2.1", "kubernetes": "4174.v4230d0ccd951", "mailer": "1.6"},
+			"jenkins": {"version": "2.426.3"}
+		}`},
+	}
+	p := &api.Project{}
+	if err := jsonpb.UnmarshalString(projectJSON, p); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		want := &api.Project{}
+		if err := jsonpb.UnmarshalString(tc.want, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ApplyProfile(p, tc.profile)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !proto.Equal(got, want) {
+			t.Errorf("%q: wanted: %s, got: %s", tc.profile, want, got)
+		}
+	}
+	// The project is not modified
+	if len(p.Profiles) != 2 || p.Dependencies["mailer"] != "1.6" {
+		t.Errorf("the project was modified: %s", p)
+	}
+
+	if _, err := ApplyProfile(p, "prod"); err == nil {
+		t.Errorf("not expected to apply an unknown profile but it could")
+	}
+	p.Profiles["dev"].Remove = []string{"credentials"}
+	if _, err := ApplyProfile(p, "dev"); err == nil {
+		t.Errorf("not expected to remove a missing dependency but it could")
+	}
+}
@@ -0,0 +1,263 @@
+// Package project reads project files. Besides the project file schema, it supports
+// the plugins.txt and plugins.yaml files used by the plugin installation manager
+// (https://github.com/jenkinsci/plugin-installation-manager-tool).
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/ghodss/yaml"
+	"github.com/juju/errors"
+)
+
+// Versions that are not pinned
+const (
+	Latest       = "latest"
+	Experimental = "experimental"
+)
+
+// Source represents a plugin requested without a pinned version
+type Source struct {
+	Name string
+	// Version is either Latest or Experimental (empty for URL sources)
+	Version string
+	// URL is the location of the plugin (empty for update site sources)
+	URL string
+}
+
+// VersionResolver resolves the version of plugins requested without a pinned version
+type VersionResolver interface {
+	ResolveVersion(*Source) (string, error)
+}
+
+// Options configures how project files are read
+type Options struct {
+	// Resolver resolves the version of plugins requested without a pinned version
+	Resolver VersionResolver
+	// Jsonnet configures the evaluation of Jsonnet project files
+	Jsonnet *utils.JsonnetOptions
+}
+
+// pimFile represents a plugin installation manager plugins.yaml file
+type pimFile struct {
+	Plugins []struct {
+		ArtifactID string `json:"artifactId"`
+		GroupID    string `json:"groupId"`
+		Source     struct {
+			Version string `json:"version"`
+			URL     string `json:"url"`
+		} `json:"source"`
+	} `json:"plugins"`
+}
+
+// Read reads a project file. The format depends on the file extension:
+//
+//   - .txt files are plugin installation manager plugins.txt files.
+//   - .yaml and .yml files are plugin installation manager plugins.yaml files if they
+//     have a top-level "plugins" list. Otherwise, they are project files.
+//   - Any other extension supported by utils.UnmarshalFile is a project file.
+//
+// Plugins without a pinned version (latest, experimental or URL sources) are resolved
+// with the options resolver, so the project only includes pinned versions.
+//
+// The project files listed in the include field are read and merged (see merge).
+func Read(filename string, opts *Options) (*api.Project, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	in, err := (&includer{opts: opts}).read(filename)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return in.project, nil
+}
+
+// readFile reads a project file without processing its includes
+func readFile(filename string, opts *Options) (*api.Project, error) {
+	r := opts.Resolver
+
+	switch filepath.Ext(filename) {
+	case ".jsonnet":
+		project := &api.Project{}
+		if err := utils.UnmarshalJsonnetWithOptions(filename, project, opts.Jsonnet); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return project, nil
+	case ".txt":
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		p, err := readTxt(data, r)
+		return p, errors.Annotatef(err, "unable to read %s", filename)
+	case ".yaml", ".yml":
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if isPIMFile(data) {
+			p, err := readPIM(data, r)
+			return p, errors.Annotatef(err, "unable to read %s", filename)
+		}
+	}
+
+	project := &api.Project{}
+	if err := utils.UnmarshalFile(filename, project); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return project, nil
+}
+
+// IsPIMFile returns whether a file is a plugin installation manager plugins.txt or
+// plugins.yaml file (see Read)
+func IsPIMFile(filename string) (bool, error) {
+	switch filepath.Ext(filename) {
+	case ".txt":
+		return true, nil
+	case ".yaml", ".yml":
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		return isPIMFile(data), nil
+	}
+	return false, nil
+}
+
+// isPIMFile returns whether a YAML file has a top-level "plugins" list
+func isPIMFile(data []byte) bool {
+	var top map[string]interface{}
+	if err := yaml.Unmarshal(data, &top); err != nil {
+		return false
+	}
+	_, ok := top["plugins"].([]interface{})
+	return ok
+}
+
+// readTxt reads a plugins.txt file. Every line is a plugin with any of the following formats:
+//
+//	name
+//	name:version
+//	name:latest
+//	name:experimental
+//	name:version:url
+func readTxt(data []byte, r VersionResolver) (*api.Project, error) {
+	deps := newDependencies(r)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		src, err := parsePlugin(line)
+		if err != nil {
+			return nil, errors.Annotatef(err, "line %d", n)
+		}
+		if err := deps.add(src); err != nil {
+			return nil, errors.Annotatef(err, "line %d", n)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &api.Project{Dependencies: deps.versions}, nil
+}
+
+// parsePlugin parses a plugin in the plugins.txt format
+func parsePlugin(plugin string) (*Source, error) {
+	fields := strings.SplitN(plugin, ":", 3)
+	if fields[0] == "" {
+		return nil, errors.Errorf("malformed plugin %q", plugin)
+	}
+	src := &Source{Name: fields[0], Version: Latest}
+	if len(fields) > 1 && fields[1] != "" {
+		src.Version = fields[1]
+	}
+	if len(fields) > 2 {
+		src.URL = fields[2]
+	}
+	return src, nil
+}
+
+// readPIM reads a plugin installation manager plugins.yaml file
+func readPIM(data []byte, r VersionResolver) (*api.Project, error) {
+	f := &pimFile{}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, errors.Trace(err)
+	}
+	deps := newDependencies(r)
+	for _, p := range f.Plugins {
+		if p.ArtifactID == "" {
+			return nil, errors.Errorf("malformed plugin: missing artifactId")
+		}
+		src := &Source{Name: p.ArtifactID, Version: p.Source.Version, URL: p.Source.URL}
+		if src.Version == "" && src.URL == "" {
+			src.Version = Latest
+		}
+		if err := deps.add(src); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return &api.Project{Dependencies: deps.versions}, nil
+}
+
+// dependencies maps the plugins sources to pinned versions
+type dependencies struct {
+	versions map[string]string
+	resolver VersionResolver
+}
+
+func newDependencies(r VersionResolver) *dependencies {
+	return &dependencies{
+		versions: map[string]string{},
+		resolver: r,
+	}
+}
+
+func (d *dependencies) add(src *Source) error {
+	if _, ok := d.versions[src.Name]; ok {
+		return errors.Errorf("duplicated plugin %s", src.Name)
+	}
+	if strings.HasPrefix(src.Version, "incrementals;") {
+		return errors.Errorf("unsupported incrementals source for the %s plugin", src.Name)
+	}
+
+	switch {
+	case src.Version != "" && src.Version != Latest && src.Version != Experimental:
+		// Plugins are always downloaded from the Jenkins update site, so the URL
+		// is only useful to find out the version of unpinned plugins.
+		if src.URL != "" {
+			log.Printf("WARNING: ignoring the %s plugin URL, %s:%s will be downloaded from the Jenkins update site\n", src.Name, src.Name, src.Version)
+		}
+		d.versions[src.Name] = src.Version
+		return nil
+	case src.URL != "":
+		// URL sources with unpinned versions are identified by their URL
+		src.Version = ""
+	}
+
+	if d.resolver == nil {
+		return errors.Errorf("unable to resolve the %s plugin version: there is no version resolver", src.Name)
+	}
+	version, err := d.resolver.ResolveVersion(src)
+	if err != nil {
+		return errors.Annotatef(err, "unable to resolve the %s plugin version", src.Name)
+	}
+	log.Printf("resolved %s plugin %s:%s\n", describe(src), src.Name, version)
+	d.versions[src.Name] = version
+	return nil
+}
+
+func describe(src *Source) string {
+	if src.URL != "" {
+		return src.URL
+	}
+	return src.Version
+}
@@ -0,0 +1,193 @@
+package project
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+)
+
+// fakeResolver resolves unpinned versions to "<version|url>-resolved"
+type fakeResolver struct{}
+
+func (fakeResolver) ResolveVersion(src *Source) (string, error) {
+	if src.Name == "unknown" {
+		return "", errors.Errorf("unknown plugin")
+	}
+	return describe(src) + "-resolved", nil
+}
+
+func TestRead(t *testing.T) {
+	unpinned := &api.Project{
+		Dependencies: map[string]string{
+			"credentials":         "2.2.0",
+			"structs":             "1.7",
+			"git":                 "latest-resolved",
+			"mailer":              "latest-resolved",
+			"workflow-aggregator": "experimental-resolved",
+			"script-security":     "https://example.com/script-security.hpi-resolved",
+		},
+	}
+	testCases := []struct {
+		file string
+		want *api.Project
+	}{
+		{"testdata/plugins.txt", unpinned},
+		{"testdata/plugins.yaml", unpinned},
+		{"testdata/project.yaml", &api.Project{
+			Dependencies: map[string]string{
+				"credentials": "2.2.0",
+				"structs":     "1.7",
+			},
+		}},
+	}
+	for _, tc := range testCases {
+		got, err := Read(tc.file, &Options{Resolver: fakeResolver{}})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !proto.Equal(got, tc.want) {
+			t.Errorf("%s: wanted: %s, got: %s", tc.file, tc.want, got)
+		}
+	}
+}
+
+func TestReadTxtMalformed(t *testing.T) {
+	testCases := []struct {
+		name string
+		txt  string
+	}{
+		{"missing name", ":1.0\n"},
+		{"duplicated plugin", "foo:1.0\nfoo:2.0\n"},
+		{"incrementals", "foo:incrementals;org.jenkins-ci.plugins;1.0-rc1\n"},
+		{"unresolved plugin", "unknown:latest\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := readTxt([]byte(tc.txt), fakeResolver{}); err == nil {
+				t.Errorf("not expected to read %q but it could", tc.txt)
+			}
+		})
+	}
+}
+
+func TestReadJsonnet(t *testing.T) {
+	opts := &Options{
+		Jsonnet: &utils.JsonnetOptions{
+			ExtVars: map[string]string{"structs": "1.7"},
+			TLAVars: map[string]string{"mailer": "1.6"},
+			JPaths:  []string{"testdata/lib"},
+		},
+	}
+	got, err := Read("testdata/project.jsonnet", opts)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	want := &api.Project{
+		Dependencies: map[string]string{
+			"google-login": "1.4",
+			"mailer":       "1.6",
+			"structs":      "1.7",
+		},
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("wanted: %s, got: %s", want, got)
+	}
+}
+
+func TestReadInclude(t *testing.T) {
+	testCases := []struct {
+		file string
+		want *api.Project
+	}{
+		{"testdata/include/team.yaml", &api.Project{
+			Dependencies: map[string]string{
+				"credentials":  "2.2.0",
+				"git":          "5.2.1",
+				"google-login": "1.4",
+				"kubernetes":   "4029.v5712230ccb_f8",
+				"mailer":       "1.6",
+			},
+			Jenkins: &api.Core{Version: "2.426.3", War: "common/jenkins.war"},
+			Profiles: map[string]*api.Profile{
+				"dev": {Dependencies: map[string]string{"pipeline-graph-view": "216.vf_1ee8d8d2c44"}},
+			},
+			Overrides: map[string]string{"structs": "1.7"},
+			Excludes:  []string{"workflow-aggregator", "pipeline-stage-view"},
+		}},
+		{"testdata/include/override.yaml", &api.Project{
+			Dependencies: map[string]string{
+				"google-login": "1.4",
+				"mailer":       "1.23",
+			},
+		}},
+	}
+	for _, tc := range testCases {
+		got, err := Read(tc.file, nil)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !proto.Equal(got, tc.want) {
+			t.Errorf("%s: wanted: %s, got: %s", tc.file, tc.want, got)
+		}
+	}
+}
+
+func TestReadIncludeErrors(t *testing.T) {
+	testCases := []struct {
+		file string
+		want []string
+	}{
+		{"testdata/include/conflict.yaml", []string{
+			"conflicting versions of the mailer plugin",
+			"testdata/include/common/auth.txt",
+			"testdata/include/mailer.txt",
+		}},
+		{"testdata/include/cycle.yaml", []string{"include cycle"}},
+		{"testdata/include/missing.yaml", []string{"missing.yaml"}},
+	}
+	for _, tc := range testCases {
+		_, err := Read(tc.file, nil)
+		if err == nil {
+			t.Fatalf("%s: expected an error but got none", tc.file)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: %q does not contain %q", tc.file, err.Error(), want)
+			}
+		}
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	testCases := []struct {
+		file string
+		want []string
+	}{
+		{"testdata/include/team.yaml", []string{
+			"testdata/include/common/base.yaml",
+			"testdata/include/common/core.json",
+			"testdata/include/common/auth.txt",
+		}},
+		{"testdata/include/cycle.yaml", []string{"testdata/include/cycle.yaml"}},
+		// Missing files are returned but not read
+		{"testdata/include/dangling.yaml", []string{
+			"testdata/include/common/auth.txt",
+			"testdata/include/other/team.yaml",
+		}},
+		{"testdata/include/mailer.txt", nil},
+	}
+	for _, tc := range testCases {
+		got, err := Includes(tc.file, nil)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: wanted: %q, got: %q", tc.file, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/downloader/httpdownloader"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jar"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jpi"
+	"github.com/juju/errors"
+)
+
+const (
+	timeoutMin    = 5
+	updateSiteURL = "https://updates.jenkins.io"
+)
+
+// UpdateSiteResolver resolves plugins versions by downloading them (from the
+// Jenkins update site or from their URL) and reading their manifest.
+type UpdateSiteResolver struct {
+	URL string
+	// WorkingDir is the plugins working dir. If set, the plugins downloaded from
+	// the update site are stored in its jpi store, so they are not downloaded again
+	// to fetch their metadata or to bundle them.
+	WorkingDir string
+}
+
+// NewUpdateSiteResolver returns a resolver for the official Jenkins update site
+func NewUpdateSiteResolver() *UpdateSiteResolver {
+	return &UpdateSiteResolver{
+		URL: updateSiteURL,
+	}
+}
+
+// GetDownloadURL returns the URL to download the plugin from
+func (r *UpdateSiteResolver) GetDownloadURL(src *Source) string {
+	switch {
+	case src.URL != "":
+		return src.URL
+	case src.Version == Experimental:
+		return fmt.Sprintf("%s/experimental/latest/%s.hpi", r.URL, src.Name)
+	}
+	return fmt.Sprintf("%s/latest/%s.hpi", r.URL, src.Name)
+}
+
+// ResolveVersion returns the version of the plugin
+func (r *UpdateSiteResolver) ResolveVersion(src *Source) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutMin*time.Minute)
+	defer cancel()
+
+	// URL sources may not be identical to the update site artifacts of the same
+	// version, so they are not stored
+	store := r.WorkingDir != "" && src.URL == ""
+	dir := ""
+	if store {
+		dir = jpi.GetStorePath(r.WorkingDir)
+	}
+	f, err := ioutil.TempFile(dir, "jpr")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	url := r.GetDownloadURL(src)
+	if err := httpdownloader.Download(ctx, url, f); err != nil {
+		return "", errors.Annotatef(err, "unable to download %q", url)
+	}
+	if err := f.Close(); err != nil {
+		return "", errors.Trace(err)
+	}
+
+	manifest, err := jar.ExtractManifest(f.Name())
+	if err != nil {
+		return "", errors.Annotatef(err, "unable to read %q", url)
+	}
+	pm, err := jpi.ParseManifest(manifest)
+	if err != nil {
+		return "", errors.Annotatef(err, "unable to read %q", url)
+	}
+	if pm.Plugin.Name != src.Name {
+		return "", errors.Errorf("%q is not the %s plugin but %s", url, src.Name, pm.Plugin.Name)
+	}
+	if store {
+		if err := os.Rename(f.Name(), jpi.GetPluginPath(pm.Plugin, r.WorkingDir)); err != nil {
+			return "", errors.Trace(err)
+		}
+	}
+	return pm.Plugin.Version, nil
+}
@@ -0,0 +1,127 @@
+package project
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/common"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/plugins/jpi"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+)
+
+// serveTestPlugin serves a minimal jpi file for the given plugin
+func serveTestPlugin(name string, version string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zw := zip.NewWriter(w)
+		f, err := zw.Create("META-INF/MANIFEST.MF")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(f, "Plugin-Version: %s\r\nShort-Name: %s\r\nLong-Name: %s\r\n", version, name, name)
+		// Uncompressed padding so the archive is large enough to sniff its mime type
+		f, err = zw.CreateHeader(&zip.FileHeader{Name: "README", Method: zip.Store})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		f.Write(bytes.Repeat([]byte(name), 512))
+		zw.Close()
+	}
+}
+
+func TestUpdateSiteResolver(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/foo.hpi", serveTestPlugin("foo", "1.0"))
+	mux.HandleFunc("/experimental/latest/foo.hpi", serveTestPlugin("foo", "2.0-beta-1"))
+	mux.HandleFunc("/custom/foo.hpi", serveTestPlugin("foo", "1.0-custom"))
+	mux.HandleFunc("/custom/bar.hpi", serveTestPlugin("foo", "1.0"))
+	site := httptest.NewServer(mux)
+	defer site.Close()
+
+	testCases := []struct {
+		src     *Source
+		want    string
+		success bool
+	}{
+		{&Source{Name: "foo", Version: Latest}, "1.0", true},
+		{&Source{Name: "foo", Version: Experimental}, "2.0-beta-1", true},
+		{&Source{Name: "foo", URL: site.URL + "/custom/foo.hpi"}, "1.0-custom", true},
+		// The URL does not serve the requested plugin
+		{&Source{Name: "bar", URL: site.URL + "/custom/bar.hpi"}, "", false},
+		{&Source{Name: "bar", Version: Latest}, "", false},
+	}
+	r := NewUpdateSiteResolver()
+	r.URL = site.URL
+	for _, tc := range testCases {
+		got, err := r.ResolveVersion(tc.src)
+		if !tc.success {
+			if err == nil {
+				t.Errorf("not expected to resolve %s (%s) but it could", tc.src.Name, describe(tc.src))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if got != tc.want {
+			t.Errorf("wanted: %s, got: %s", tc.want, got)
+		}
+	}
+}
+
+func TestUpdateSiteResolverStore(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/foo.hpi", serveTestPlugin("foo", "1.0"))
+	mux.HandleFunc("/custom/bar.hpi", serveTestPlugin("bar", "1.0-custom"))
+	site := httptest.NewServer(mux)
+	defer site.Close()
+
+	workingDir, err := ioutil.TempDir("", "jpr")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer os.RemoveAll(workingDir)
+	if err := common.EnsureStorePathExists(workingDir, jpi.GetStorePath); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	r := NewUpdateSiteResolver()
+	r.URL = site.URL
+	r.WorkingDir = workingDir
+	testCases := []struct {
+		src    *Source
+		plugin *api.Plugin
+		stored bool
+	}{
+		{&Source{Name: "foo", Version: Latest}, &api.Plugin{Name: "foo", Version: "1.0"}, true},
+		// URL sources may differ from the update site artifacts
+		{&Source{Name: "bar", URL: site.URL + "/custom/bar.hpi"}, &api.Plugin{Name: "bar", Version: "1.0-custom"}, false},
+	}
+	for _, tc := range testCases {
+		if _, err := r.ResolveVersion(tc.src); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		stored, err := utils.FileExists(jpi.GetPluginPath(tc.plugin, workingDir))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if stored != tc.stored {
+			t.Errorf("%s: wanted stored: %v, got: %v", tc.plugin.Identifier(), tc.stored, stored)
+		}
+	}
+	files, err := ioutil.ReadDir(jpi.GetStorePath(workingDir))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("unexpected files in the jpi store: %d", len(files))
+	}
+}
@@ -0,0 +1,3 @@
+{
+  'google-login': '1.4',
+}
@@ -0,0 +1,41 @@
+package project
+
+import (
+	"path/filepath"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/juju/errors"
+)
+
+// SetVersions writes the versions of some plugins to the dependencies of a JSON or
+// YAML project file. The rest of the project is kept, but comments and formatting
+// are not preserved. The versions of a project file override the ones of the files
+// it includes, so the included files are not modified.
+func SetVersions(filename string, versions map[string]string) error {
+	switch filepath.Ext(filename) {
+	case ".json", ".yaml", ".yml":
+	default:
+		return errors.Errorf("unable to write the versions to %s: only JSON and YAML project files are supported", filename)
+	}
+
+	// The plugin installation manager files are not project files, so they
+	// would be rewritten without their plugins list
+	if pim, err := IsPIMFile(filename); err != nil {
+		return errors.Trace(err)
+	} else if pim {
+		return errors.Errorf("unable to write the versions to %s: plugin installation manager files are not supported", filename)
+	}
+
+	p := &api.Project{}
+	if err := utils.UnmarshalFile(filename, p); err != nil {
+		return errors.Annotatef(err, "unable to read %s", filename)
+	}
+	if p.Dependencies == nil {
+		p.Dependencies = map[string]string{}
+	}
+	for name, version := range versions {
+		p.Dependencies[name] = version
+	}
+	return errors.Annotatef(utils.MarshalFile(filename, p), "unable to write %s", filename)
+}
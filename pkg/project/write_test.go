@@ -0,0 +1,72 @@
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/api"
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestSetVersions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	testCases := []struct {
+		file string
+		data string
+	}{
+		{"project.json", `{"include": ["base.yaml"], "dependencies": {"git": "5.2.1", "mailer": "1.6"}, "excludes": ["foo"]}`},
+		{"project.yaml", "include:\n  - base.yaml\ndependencies:\n  git: 5.2.1\n  mailer: '1.6'\nexcludes:\n  - foo\n"},
+	}
+	want := &api.Project{
+		Include:      []string{"base.yaml"},
+		Dependencies: map[string]string{"git": "5.2.1", "mailer": "1.20", "kubernetes": "4029.v5712230ccb_f8"},
+		Excludes:     []string{"foo"},
+	}
+	for _, tc := range testCases {
+		filename := filepath.Join(tmpDir, tc.file)
+		if err := ioutil.WriteFile(filename, []byte(tc.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetVersions(filename, map[string]string{"mailer": "1.20", "kubernetes": "4029.v5712230ccb_f8"}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		got := &api.Project{}
+		if err := utils.UnmarshalFile(filename, got); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !proto.Equal(got, want) {
+			t.Errorf("%s: wanted: %s, got: %s", tc.file, want, got)
+		}
+	}
+
+	for _, file := range []string{"testdata/plugins.txt", "testdata/project.jsonnet"} {
+		if err := SetVersions(file, map[string]string{"mailer": "1.20"}); err == nil {
+			t.Errorf("%s: expected an error but it could write the versions", file)
+		}
+	}
+
+	// plugins.yaml files are not rewritten, so their plugins list is not lost
+	data := "plugins:\n  - artifactId: mailer\n    source:\n      version: '1.6'\n"
+	filename := filepath.Join(tmpDir, "plugins.yaml")
+	if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetVersions(filename, map[string]string{"mailer": "1.20"}); err == nil {
+		t.Errorf("%s: expected an error but it could write the versions", filename)
+	}
+	got, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != data {
+		t.Errorf("%s: wanted: %q, got: %q", filename, data, string(got))
+	}
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+	"github.com/juju/errors"
+)
+
+// JsonnetOptions configures the evaluation of Jsonnet files
+type JsonnetOptions struct {
+	// ExtVars are the external variables as strings (std.extVar)
+	ExtVars map[string]string
+	// ExtCode are the external variables as Jsonnet code (std.extVar)
+	ExtCode map[string]string
+	// TLAVars are the top-level arguments as strings
+	TLAVars map[string]string
+	// TLACode are the top-level arguments as Jsonnet code
+	TLACode map[string]string
+	// JPaths are the library search paths. Relative imports are
+	// looked up in the importing file directory first.
+	JPaths []string
+}
+
+func (o *JsonnetOptions) makeVM(filename string) *jsonnet.VM {
+	vm := jsonnet.MakeVM()
+	vm.ErrorFormatter = jsonnetErrorFormatter{filename: filename}
+	if o == nil {
+		return vm
+	}
+	for k, v := range o.ExtVars {
+		vm.ExtVar(k, v)
+	}
+	for k, v := range o.ExtCode {
+		vm.ExtCode(k, v)
+	}
+	for k, v := range o.TLAVars {
+		vm.TLAVar(k, v)
+	}
+	for k, v := range o.TLACode {
+		vm.TLACode(k, v)
+	}
+	vm.Importer(&jsonnet.FileImporter{JPaths: o.JPaths})
+	return vm
+}
+
+// UnmarshalJsonnetWithOptions unmarshals a Jsonnet file into a protocol buffer
+func UnmarshalJsonnetWithOptions(filename string, pb proto.Message, opts *JsonnetOptions) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	json, err := opts.makeVM(filename).EvaluateSnippet(filename, string(data))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(jsonpb.UnmarshalString(json, pb), "%s", filename)
+}
+
+// jsonnetErrorFormatter formats Jsonnet errors as "file:line:column: message".
+// Errors without location (e.g. missing top-level arguments) are formatted as
+// "file: message", where file is the evaluated file.
+type jsonnetErrorFormatter struct {
+	filename string
+}
+
+func (ef jsonnetErrorFormatter) Format(err error) string {
+	switch err := err.(type) {
+	case jsonnet.RuntimeError:
+		// The first located frame is where the error was raised
+		for _, f := range err.StackTrace {
+			if f.Loc.IsSet() {
+				return fmt.Sprintf("%s: %s", formatJsonnetLocation(f.Loc), err.Msg)
+			}
+		}
+		return fmt.Sprintf("%s: %s", ef.filename, err.Msg)
+	case interface {
+		error
+		Loc() ast.LocationRange
+	}:
+		// Static errors are formatted as "<location range> <message>"
+		loc := err.Loc()
+		if !loc.IsSet() {
+			return fmt.Sprintf("%s: %s", ef.filename, err.Error())
+		}
+		return fmt.Sprintf("%s: %s", formatJsonnetLocation(loc), strings.TrimPrefix(err.Error(), loc.String()+" "))
+	}
+	return fmt.Sprintf("%s: %s", ef.filename, err.Error())
+}
+
+func (jsonnetErrorFormatter) SetMaxStackTraceSize(size int) {}
+
+func (jsonnetErrorFormatter) SetColorFormatter(color jsonnet.ColorFormatter) {}
+
+func formatJsonnetLocation(loc ast.LocationRange) string {
+	filename := loc.FileName
+	if loc.File != nil {
+		filename = string(loc.File.DiagnosticFileName)
+	}
+	return fmt.Sprintf("%s:%d:%d", filename, loc.Begin.Line, loc.Begin.Column)
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/jenkins-plugins-resolver/pkg/utils/testdata/example"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestUnmarshalJsonnetWithOptions(t *testing.T) {
+	opts := &JsonnetOptions{
+		ExtVars: map[string]string{"prefix": "my-"},
+		TLACode: map[string]string{"foo": "100 + 23"},
+		JPaths:  []string{"testdata/jsonnet/lib"},
+	}
+	msg := &example.Test{}
+	if err := UnmarshalJsonnetWithOptions("testdata/jsonnet/options.jsonnet", msg, opts); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	want := &example.Test{Foo: 123, Bar: "my-string"}
+	if !proto.Equal(msg, want) {
+		t.Errorf("got: %s, wanted: %s", msg, want)
+	}
+}
+
+func TestUnmarshalJsonnetErrors(t *testing.T) {
+	testCases := []struct {
+		file string
+		opts *JsonnetOptions
+		want string
+	}{
+		{"testdata/jsonnet/runtime-error.jsonnet", nil, "testdata/jsonnet/runtime-error.jsonnet:3:8: no bar"},
+		{"testdata/jsonnet/static-error.jsonnet", nil, "testdata/jsonnet/static-error.jsonnet:4:3: "},
+		{"testdata/jsonnet/options.jsonnet", nil, "testdata/jsonnet/options.jsonnet: Missing argument: foo"},
+		// The library paths are required to import the library
+		{"testdata/jsonnet/options.jsonnet", &JsonnetOptions{TLAVars: map[string]string{"foo": "123"}}, "testdata/jsonnet/options.jsonnet:3:15: couldn't open import \"test.libsonnet\""},
+	}
+	for _, tc := range testCases {
+		err := UnmarshalJsonnetWithOptions(tc.file, &example.Test{}, tc.opts)
+		if err == nil {
+			t.Fatalf("%s: expected an error but got none", tc.file)
+		}
+		if !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("%s: got: %q, wanted prefix: %q", tc.file, err.Error(), tc.want)
+		}
+	}
+}
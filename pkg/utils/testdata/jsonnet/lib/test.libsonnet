@@ -0,0 +1,3 @@
+{
+  bar: 'string',
+}
@@ -7,11 +7,11 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/ghodss/yaml"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
-	"github.com/google/go-jsonnet"
 	"github.com/hashicorp/go-version"
 	"github.com/juju/errors"
 	"github.com/mkmik/multierror"
@@ -39,17 +39,9 @@ func UnmarshalJSON(filename string, pb proto.Message) error {
 	return jsonpb.Unmarshal(r, pb)
 }
 
-// UnmarshalJsonnet unmarshals a JSON file into a protocol buffer
+// UnmarshalJsonnet unmarshals a Jsonnet file into a protocol buffer
 func UnmarshalJsonnet(filename string, pb proto.Message) error {
-	data, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return errors.Trace(err)
-	}
-	json, err := jsonnet.MakeVM().EvaluateSnippet(filename, string(data))
-	if err != nil {
-		return errors.Trace(err)
-	}
-	return jsonpb.UnmarshalString(json, pb)
+	return UnmarshalJsonnetWithOptions(filename, pb, nil)
 }
 
 // UnmarshalYAML unmarshals a YAML file into a protocol buffer
@@ -70,6 +62,36 @@ func UnmarshalYAML(filename string, pb proto.Message) error {
 	return jsonpb.UnmarshalString(string(jsb), pb)
 }
 
+// TxtMarshaler is implemented by the protocol buffers that can be represented
+// as plugins.txt files (one "name:version" plugin per line), the format used by
+// the official Jenkins Docker image and the plugin installation manager.
+type TxtMarshaler interface {
+	MarshalTxt() ([]byte, error)
+	UnmarshalTxt([]byte) error
+}
+
+// UnmarshalTxt unmarshals a plugins.txt file into a protocol buffer
+func UnmarshalTxt(filename string, pb proto.Message) error {
+	tm, ok := pb.(TxtMarshaler)
+	if !ok {
+		return errors.Errorf("%T can not be read from plugins.txt files", pb)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(tm.UnmarshalTxt(data), "unable to read %s", filename)
+}
+
+// UnmarshalTextproto unmarshals a text format file into a protocol buffer
+func UnmarshalTextproto(filename string, pb proto.Message) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return proto.UnmarshalText(string(data), pb)
+}
+
 // MarshalJSON marshals a protocol buffer into a JSON file.
 func MarshalJSON(filename string, pb proto.Message) error {
 	f, err := os.Create(filename)
@@ -82,6 +104,59 @@ func MarshalJSON(filename string, pb proto.Message) error {
 	return m.Marshal(f, pb)
 }
 
+// MarshalYAML marshals a protocol buffer into a YAML file. Fields are named
+// after the protocol buffer fields, as UnmarshalYAML expects.
+func MarshalYAML(filename string, pb proto.Message) error {
+	m := &jsonpb.Marshaler{OrigName: true}
+	json, err := m.MarshalToString(pb)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data, err := yaml.JSONToYAML([]byte(json))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// MarshalTxt marshals a protocol buffer into a plugins.txt file
+func MarshalTxt(filename string, pb proto.Message) error {
+	tm, ok := pb.(TxtMarshaler)
+	if !ok {
+		return errors.Errorf("%T can not be written to plugins.txt files", pb)
+	}
+	data, err := tm.MarshalTxt()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// MarshalTextproto marshals a protocol buffer into a text format file
+func MarshalTextproto(filename string, pb proto.Message) error {
+	return ioutil.WriteFile(filename, []byte(proto.MarshalTextString(pb)), 0644)
+}
+
+// MarshalFile marshals a protocol buffer into a file. The format
+// depends on the file extension.
+func MarshalFile(filename string, pb proto.Message) error {
+	var marshal func(string, proto.Message) error
+	switch filepath.Ext(filename) {
+	case ".json":
+		marshal = MarshalJSON
+	case ".yaml", ".yml":
+		marshal = MarshalYAML
+	case ".txt":
+		marshal = MarshalTxt
+	case ".textproto":
+		marshal = MarshalTextproto
+	}
+	if marshal == nil {
+		return errors.Errorf("unsupported output file type: %s\n", filename)
+	}
+	return errors.Trace(marshal(filename, pb))
+}
+
 // UnmarshalFile unmarshals a file into a protocol buffer
 func UnmarshalFile(filename string, pb proto.Message) error {
 	var unmarshal func(string, proto.Message) error
@@ -92,6 +167,10 @@ func UnmarshalFile(filename string, pb proto.Message) error {
 		unmarshal = UnmarshalJsonnet
 	case ".yaml", ".yml":
 		unmarshal = UnmarshalYAML
+	case ".txt":
+		unmarshal = UnmarshalTxt
+	case ".textproto":
+		unmarshal = UnmarshalTextproto
 	}
 	if unmarshal == nil {
 		return errors.Errorf("unsupported input file type: %s\n", filename)
@@ -102,6 +181,11 @@ func UnmarshalFile(filename string, pb proto.Message) error {
 	return nil
 }
 
+// MajorVersion returns the first component of a version
+func MajorVersion(v string) string {
+	return strings.SplitN(v, ".", 2)[0]
+}
+
 // VersionLower returns whether i version is lower than j version
 func VersionLower(i string, j string) (bool, error) {
 	var errs error
@@ -105,6 +105,49 @@ func TestMarshalJSON(t *testing.T) {
 	}
 }
 
+func TestMarshalFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "marshal")
+	if err != nil {
+		t.Fatalf("%+v\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		file    string
+		success bool
+	}{
+		{"test.json", true},
+		{"test.yaml", true},
+		{"test.yml", true},
+		{"test.textproto", true},
+		// The example message does not implement TxtMarshaler
+		{"test.txt", false},
+		{"test.foo", false},
+	}
+	want := &example.Test{Foo: 123, Bar: "string"}
+	for _, tc := range testCases {
+		file := filepath.Join(dir, tc.file)
+		err := MarshalFile(file, want)
+		if !tc.success {
+			if err == nil {
+				t.Errorf("not expected to marshal %s but it could\n", tc.file)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v\n", err)
+		}
+
+		got := &example.Test{}
+		if err := UnmarshalFile(file, got); err != nil {
+			t.Fatalf("%+v\n", err)
+		}
+		if !proto.Equal(got, want) {
+			t.Errorf("%s: got: %q, wanted: %q\n", tc.file, got, want)
+		}
+	}
+}
+
 func TestVersionLower(t *testing.T) {
 	testCases := []struct {
 		vi   string